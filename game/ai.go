@@ -0,0 +1,270 @@
+package game
+
+// AIState is what an enemy is doing at the moment
+type AIState int
+
+const (
+	// Idle enemies sit still and hold their fire
+	Idle AIState = iota
+	// Patrol enemies loop through their patrol waypoints without firing
+	Patrol
+	// Chase enemies drive after the player until within attack range, firing whenever they can see it
+	Chase
+	// Attack enemies keep their attack range from the player, backing off if it comes closer, and fire
+	Attack
+	// Retreat enemies drive back to where they spawned without firing
+	Retreat
+	// Advance enemies drive along their lane toward the goal, firing at the player whenever they can see it
+	Advance
+	// Stand enemies hold their ground and fire at the player. Enemies that hold stand whenever they
+	// see it, and retreating enemies turn to stand once cornered, at home or with the player too
+	// close, until they lose sight of it.
+	Stand
+)
+
+var aiStateNames = [...]string{"idle", "patrol", "chase", "attack", "retreat", "advance", "stand"}
+
+func (state AIState) String() string {
+	if state < 0 || int(state) >= len(aiStateNames) {
+		return "unknown"
+	}
+	return aiStateNames[state]
+}
+
+// Enemies without their own sight or attack range get these, in pixels
+const (
+	DefaultSight       = 900.0
+	DefaultAttackRange = 250.0
+)
+
+// TooClose is the fraction of its attack range within which the player is too close for an enemy:
+// attacking enemies back off, and retreating ones turn to stand
+const TooClose = 0.75
+
+// AIParams tune how an enemy archetype's behavior plays out
+type AIParams struct {
+	// Sight is how far away the enemy notices the player, given a clear line of sight
+	Sight float64 `json:"sight"`
+	// AttackRange is the distance the enemy likes to fight the player from
+	AttackRange float64 `json:"attackRange"`
+	// RetreatBelow is the fraction of its hitpoints below which the enemy retreats, for behaviors that do
+	RetreatBelow float64 `json:"retreatBelow"`
+	// Patrol lists the waypoints the enemy loops through, as tile offsets from where it spawned
+	Patrol []Pos `json:"patrol"`
+}
+
+// defaultPatrol walks a square a few tiles across
+var defaultPatrol = []Pos{{0, 0}, {3, 0}, {3, 3}, {0, 3}}
+
+// Senses is what an enemy knows about the player when it decides what to do
+type Senses struct {
+	Distance float64
+	// CanSee is set when the player is within sight and nothing blocks the view
+	CanSee bool
+	// Health is the fraction of its hitpoints the enemy has left
+	Health float64
+	// AtHome is set while the enemy is back where it spawned
+	AtHome bool
+}
+
+// A Behavior decides which state an enemy should be in from what it senses
+type Behavior interface {
+	// Start is the state an enemy is in when it spawns, before it has sensed anything
+	Start() AIState
+	Next(enemy *Enemy, senses Senses) AIState
+}
+
+// StateMachine is the common shape of enemy behavior: an enemy is Unaware until it sees the
+// player, then Engages it
+type StateMachine struct {
+	Unaware AIState
+	Engage  AIState
+	// Pursues sends the enemy chasing after the player if it slips out of view while still within sight range
+	Pursues bool
+	// Retreats lets the enemy fall back when its health drops below its RetreatBelow
+	Retreats bool
+}
+
+func (machine StateMachine) Start() AIState {
+	return machine.Unaware
+}
+
+func (machine StateMachine) Next(enemy *Enemy, senses Senses) AIState {
+	switch {
+	case machine.Retreats && senses.Health < enemy.AI.RetreatBelow:
+		if senses.CanSee && (enemy.State == Stand || senses.AtHome || senses.Distance < enemy.AI.AttackRange*TooClose) {
+			return Stand
+		}
+		return Retreat
+	case senses.CanSee:
+		return machine.Engage
+	case machine.Pursues && senses.Distance <= enemy.AI.Sight && enemy.State != machine.Unaware:
+		return Chase
+	default:
+		return machine.Unaware
+	}
+}
+
+// Behaviors holds every behavior archetypes may name. New ones can be added before data is loaded.
+var Behaviors = map[string]Behavior{
+	"hold":     StateMachine{Unaware: Idle, Engage: Stand},
+	"chase":    StateMachine{Unaware: Idle, Engage: Chase, Pursues: true},
+	"patrol":   StateMachine{Unaware: Patrol, Engage: Chase, Pursues: true, Retreats: true},
+	"skirmish": StateMachine{Unaware: Patrol, Engage: Attack, Pursues: true, Retreats: true},
+}
+
+// think senses the player, picks the enemy's state for this step of dt seconds and acts on it.
+// Enemies on a lane always advance along it, whatever their behavior.
+func (enemy *Enemy) think(level *Level, dt float64) {
+	player := level.Player
+	senses := Senses{}
+	senses.Distance = enemy.Vec.Dist(player.Vec)
+	senses.CanSee = senses.Distance <= enemy.AI.Sight && level.LineOfSight(enemy.Vec, player.Vec)
+	senses.Health = float64(enemy.Hitpoints) / float64(enemy.MaxHitpoints)
+	senses.AtHome = enemy.Vec.Dist(enemy.Home) <= TileSize/2
+	if enemy.Lane != nil {
+		enemy.State = Advance
+	} else {
+		enemy.State = enemy.Behavior.Next(enemy, senses)
+	}
+
+	enemy.Vel = Vec{}
+	enemy.IsFiring = false
+	enemy.Target = &player.Character
+	switch enemy.State {
+	case Advance:
+		enemy.followLane(level, dt)
+		enemy.IsFiring = senses.CanSee
+		// Without the player in view, enemies on a lane shoot at the base as they close in on it
+		if base := level.Base; !enemy.IsFiring && !base.IsDestroyed && enemy.Vec.Dist(base.Vec) <= enemy.AI.AttackRange &&
+			level.LineOfSight(enemy.Vec, base.Vec) {
+			enemy.Target = &base.Character
+			enemy.IsFiring = true
+		}
+	case Patrol:
+		enemy.patrol(level, dt)
+	case Chase:
+		if senses.Distance > enemy.AI.AttackRange {
+			enemy.driveTo(level, player.Vec, dt)
+		}
+		enemy.IsFiring = senses.CanSee
+	case Attack:
+		if senses.Distance > enemy.AI.AttackRange {
+			enemy.driveTo(level, player.Vec, dt)
+		} else if senses.Distance < enemy.AI.AttackRange*TooClose {
+			enemy.moveStraight(level, enemy.Vec.Sub(player.Vec).Normalize(), dt)
+		}
+		enemy.IsFiring = senses.CanSee
+	case Retreat:
+		if enemy.Vec.Dist(enemy.Home) > 1 {
+			enemy.driveTo(level, enemy.Home, dt)
+		}
+	case Stand:
+		enemy.IsFiring = senses.CanSee
+	}
+}
+
+// patrol drives the enemy on to its next patrol waypoint, moving on to the one after once it arrives
+func (enemy *Enemy) patrol(level *Level, dt float64) {
+	waypoints := enemy.AI.Patrol
+	if len(waypoints) == 0 {
+		waypoints = defaultPatrol
+	}
+	home := level.Map.TileAt(enemy.Home)
+	offset := waypoints[enemy.patrolIndex%len(waypoints)]
+	target := level.Map.Center(Pos{home.X + offset.X, home.Y + offset.Y})
+	if enemy.Vec.Dist(target) < 1 {
+		enemy.patrolIndex++
+		return
+	}
+	enemy.driveTo(level, target, dt)
+	if enemy.Path == nil {
+		// The waypoint cannot be reached, so skip it
+		enemy.patrolIndex++
+	}
+}
+
+// moveStraight moves the enemy along dir, if that does not take it into a tile it cannot enter
+func (enemy *Enemy) moveStraight(level *Level, dir Vec, dt float64) {
+	next := enemy.Vec.Add(dir.Scale(enemy.Speed * dt))
+	p := level.Map.TileAt(next)
+	if tile := level.Map.At(p); p != level.Map.TileAt(enemy.Vec) && (tile == nil || !tile.Open()) {
+		return
+	}
+	enemy.Vel = dir.Scale(enemy.Speed)
+	enemy.Vec = next
+}
+
+// LineOfSight reports whether nothing that blocks the view, impassable ground or obstacles,
+// stands on the tiles between a and b. Towers and units can be seen past, and whatever stands on
+// b's own tile, such as the base, does not hide b.
+func (level *Level) LineOfSight(a, b Vec) bool {
+	end := level.Map.TileAt(b)
+	steps := int(a.Dist(b)/(TileSize/4)) + 1
+	for i := 0; i <= steps; i++ {
+		p := level.Map.TileAt(a.Add(b.Sub(a).Scale(float64(i) / float64(steps))))
+		if tile := level.Map.At(p); p != end && tile != nil && (tile.Impassable || tile.Obstacle != "") {
+			return false
+		}
+	}
+	return true
+}
+
+// driveTo moves the enemy along a planned path of tiles toward the world point goal, then
+// straight to goal once it shares goal's tile. The path is planned again whenever the goal
+// changes tile, something is built or moves into its way, or ReplanTicks pass.
+func (enemy *Enemy) driveTo(level *Level, goal Vec, dt float64) {
+	goalTile := level.Map.TileAt(goal)
+	enemy.replanTimer--
+	if enemy.replanTimer <= 0 || goalTile != enemy.pathGoal || enemy.pathBlocked(level) {
+		enemy.Path = level.Map.FindPath(level.Map.TileAt(enemy.Vec), goalTile, level.passableFor(goalTile))
+		enemy.pathGoal = goalTile
+		enemy.replanTimer = ReplanTicks
+	}
+	if enemy.Path == nil {
+		return
+	}
+
+	start := enemy.Vec
+	remaining := enemy.Speed * dt
+	for remaining > 0 {
+		target := goal
+		if len(enemy.Path) > 0 {
+			target = level.Map.Center(enemy.Path[0])
+		}
+		toTarget := target.Sub(enemy.Vec)
+		dist := toTarget.Len()
+		if dist > remaining {
+			enemy.Vec = enemy.Vec.Add(toTarget.Scale(remaining / dist))
+			break
+		}
+		enemy.Vec = target
+		remaining -= dist
+		if len(enemy.Path) == 0 {
+			break
+		}
+		enemy.Path = enemy.Path[1:]
+	}
+	enemy.Vel = enemy.Vec.Sub(start).Scale(1 / dt)
+}
+
+// pathBlocked reports whether any tile left on the enemy's path has stopped being passable
+func (enemy *Enemy) pathBlocked(level *Level) bool {
+	passable := level.passableFor(enemy.pathGoal)
+	for _, p := range enemy.Path {
+		if !passable(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// passableFor says which tiles an enemy heading for goal may drive through: open tiles, other
+// than the one the player is on unless the player is what it is after
+func (level *Level) passableFor(goal Pos) func(Pos) bool {
+	playerTile := level.Map.TileAt(level.Player.Vec)
+	return func(p Pos) bool {
+		tile := level.Map.At(p)
+		return tile != nil && tile.Open() && (p == goal || p != playerTile)
+	}
+}
@@ -0,0 +1,71 @@
+package game
+
+import "testing"
+
+// clearRow opens every tile in row y between the columns from and to
+func clearRow(tileMap *TileMap, y, from, to int) {
+	for x := from; x <= to; x++ {
+		if tile := tileMap.At(Pos{x, y}); tile != nil {
+			tile.Impassable = false
+			tile.Obstacle = ""
+		}
+	}
+}
+
+func TestEnemiesSpawnUnaware(t *testing.T) {
+	game := newTestGame(t, 1)
+	for name, archetype := range game.Level.EnemyCatalog {
+		enemy := game.Level.InitEnemy(archetype)
+		if want := Behaviors[archetype.Behavior].Start(); enemy.State != want {
+			t.Errorf("%s spawned %v, want %v", name, enemy.State, want)
+		}
+	}
+}
+
+func TestEnemyDoesNotChaseUnseenPlayer(t *testing.T) {
+	game := newTestGame(t, 1)
+	level := game.Level
+	player := level.Player
+	p := level.Map.TileAt(player.Vec)
+	clearRow(level.Map, p.Y, p.X, p.X+6)
+	level.Map.At(Pos{p.X + 2, p.Y}).Impassable = true
+
+	at := level.Map.Center(Pos{p.X + 5, p.Y})
+	enemy, err := level.SpawnEnemy("gunner", &at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enemy.think(level, 1.0/60)
+	if enemy.State != Patrol {
+		t.Errorf("gunner that never saw the player is in %v, want %v", enemy.State, Patrol)
+	}
+}
+
+func TestHoldStandsItsGround(t *testing.T) {
+	game := newTestGame(t, 1)
+	level := game.Level
+	player := level.Player
+	p := level.Map.TileAt(player.Vec)
+	clearRow(level.Map, p.Y, p.X, p.X+7)
+
+	// Beyond its attack range but within sight
+	at := level.Map.Center(Pos{p.X + 7, p.Y})
+	enemy, err := level.SpawnEnemy("heavy", &at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 60; i++ {
+		enemy.think(level, 1.0/60)
+	}
+	if enemy.State != Stand || !enemy.IsFiring || enemy.Vec != at {
+		t.Errorf("heavy in view is in %v at %v, firing %v; want it standing at %v and firing", enemy.State, enemy.Vec, enemy.IsFiring, at)
+	}
+
+	level.Map.At(Pos{p.X + 3, p.Y}).Impassable = true
+	for i := 0; i < 60; i++ {
+		enemy.think(level, 1.0/60)
+	}
+	if enemy.State != Idle || enemy.Vec != at {
+		t.Errorf("heavy that lost sight is in %v at %v, want %v at %v", enemy.State, enemy.Vec, Idle, at)
+	}
+}
@@ -0,0 +1,55 @@
+package game
+
+import "math"
+
+// DefaultBulletSpeed is how fast bullets fly, in pixels per second, unless a weapon says otherwise
+const DefaultBulletSpeed = 1200.0
+
+// Intercept is the point where a shot fired from from at speed meets a target at target moving
+// with velocity vel, assuming the target keeps going the way it is. It reports false, and returns
+// target, if the shot cannot catch up.
+func Intercept(from, target, vel Vec, speed float64) (Vec, bool) {
+	// Solve |target + vel*t - from| = speed*t for the earliest t >= 0
+	offset := target.Sub(from)
+	a := vel.Dot(vel) - speed*speed
+	b := 2 * offset.Dot(vel)
+	c := offset.Dot(offset)
+	var t float64
+	if math.Abs(a) < 1e-9 {
+		// The target is as fast as the shot, so only one root is left
+		if b >= 0 {
+			return target, false
+		}
+		t = -c / b
+	} else {
+		disc := b*b - 4*a*c
+		if disc < 0 {
+			return target, false
+		}
+		root := math.Sqrt(disc)
+		t1, t2 := (-b-root)/(2*a), (-b+root)/(2*a)
+		t = math.Min(t1, t2)
+		if t < 0 {
+			t = math.Max(t1, t2)
+		}
+		if t < 0 {
+			return target, false
+		}
+	}
+	return target.Add(vel.Scale(t)), true
+}
+
+// LeadDirection is the unit vector to fire along from from to hit a target at target moving with
+// velocity vel, or straight at it if the shot cannot catch up
+func LeadDirection(from, target, vel Vec, speed float64) Vec {
+	aim, _ := Intercept(from, target, vel, speed)
+	return aim.Sub(from).Normalize()
+}
+
+// Jitter turns dir by a random angle of up to degrees either way, drawn from the level's random source
+func (level *Level) Jitter(dir Vec, degrees float64) Vec {
+	if degrees <= 0 {
+		return dir
+	}
+	return dir.Rotate((level.Rand.Float64()*2 - 1) * degrees)
+}
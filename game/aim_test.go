@@ -0,0 +1,39 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIntercept(t *testing.T) {
+	tests := []struct {
+		name              string
+		from, target, vel Vec
+		speed             float64
+		want              Vec
+		wantOk            bool
+	}{
+		{"standing still", Vec{0, 0}, Vec{1000, 0}, Vec{0, 0}, 1200, Vec{1000, 0}, true},
+		{"crossing", Vec{0, 0}, Vec{1000, 0}, Vec{0, 300}, 1200, Vec{1000, 258.19888974716116}, true},
+		{"coming closer", Vec{0, 0}, Vec{1000, 0}, Vec{-200, 0}, 1200, Vec{1000 - 200.0*1000/1400, 0}, true},
+		{"running away", Vec{0, 0}, Vec{1000, 0}, Vec{200, 0}, 1200, Vec{1000 + 200.0*1000/1000, 0}, true},
+		{"too fast to catch", Vec{0, 0}, Vec{1000, 0}, Vec{1500, 0}, 1200, Vec{1000, 0}, false},
+		{"as fast, heading in", Vec{0, 0}, Vec{1000, 0}, Vec{-1200, 0}, 1200, Vec{500, 0}, true},
+		{"as fast, crossing", Vec{0, 0}, Vec{1000, 0}, Vec{0, 1200}, 1200, Vec{1000, 0}, false},
+	}
+	for _, test := range tests {
+		got, ok := Intercept(test.from, test.target, test.vel, test.speed)
+		if ok != test.wantOk || got.Dist(test.want) > 1e-6 {
+			t.Errorf("%s: Intercept = %v, %v, want %v, %v", test.name, got, ok, test.want, test.wantOk)
+		}
+		if !ok || test.vel.Len() == 0 {
+			continue
+		}
+		// The shot and the target must arrive together
+		shotTime := got.Sub(test.from).Len() / test.speed
+		targetTime := got.Sub(test.target).Len() / test.vel.Len()
+		if math.Abs(shotTime-targetTime) > 1e-9 {
+			t.Errorf("%s: the shot takes %vs and the target %vs", test.name, shotTime, targetTime)
+		}
+	}
+}
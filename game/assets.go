@@ -0,0 +1,49 @@
+package game
+
+import (
+	"image"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SpriteID names a sprite independently of how it is rendered; the gui maps it to a texture
+// and the game only ever needs its size
+type SpriteID string
+
+// SpriteMeta holds the pixel size of every known sprite
+type SpriteMeta map[SpriteID]Size
+
+// LoadSpriteMeta reads the size of every png in dirName from its header, without decoding the
+// pixels or needing a renderer. Each sprite is named after its file with the extension removed.
+func LoadSpriteMeta(dirName string) (SpriteMeta, error) {
+	files, err := filepath.Glob(filepath.Join(dirName, "*.png"))
+	if err != nil {
+		return nil, err
+	}
+
+	meta := make(SpriteMeta, len(files))
+	for _, file := range files {
+		size, err := readImageSize(file)
+		if err != nil {
+			return nil, err
+		}
+		meta[SpriteID(strings.TrimSuffix(filepath.Base(file), ".png"))] = size
+	}
+	return meta, nil
+}
+
+func readImageSize(filename string) (Size, error) {
+	infile, err := os.Open(filename)
+	if err != nil {
+		return Size{}, err
+	}
+	defer infile.Close()
+
+	config, _, err := image.DecodeConfig(infile)
+	if err != nil {
+		return Size{}, err
+	}
+	return Size{config.Width, config.Height}, nil
+}
@@ -0,0 +1,251 @@
+package game
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Data is everything the simulation reads from disk before a game starts
+type Data struct {
+	Sprites SpriteMeta
+	Towers  *TowerCatalog
+	Enemies EnemyCatalog
+	Weapons *WeaponCatalog
+	Waves   []*Wave
+	Lanes   *LaneSet
+}
+
+// LoadData reads sprite sizes from the images in imageDir and the game's data files from dataDir
+func LoadData(imageDir, dataDir string) (*Data, error) {
+	data := &Data{}
+	var err error
+	if data.Sprites, err = LoadSpriteMeta(imageDir); err != nil {
+		return nil, err
+	}
+	if data.Towers, err = LoadTowerCatalog(filepath.Join(dataDir, "towers.json")); err != nil {
+		return nil, err
+	}
+	if data.Enemies, err = LoadEnemyCatalog(filepath.Join(dataDir, "enemies.json")); err != nil {
+		return nil, err
+	}
+	if data.Weapons, err = LoadWeaponCatalog(filepath.Join(dataDir, "weapons.json")); err != nil {
+		return nil, err
+	}
+	if data.Waves, err = LoadWaves(filepath.Join(dataDir, "waves.json")); err != nil {
+		return nil, err
+	}
+	if data.Lanes, err = LoadLanes(filepath.Join(dataDir, "lanes.json")); err != nil {
+		return nil, err
+	}
+	if err := data.validate(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// validate checks each catalog on its own, and that the data files only refer to sprites,
+// enemies and lanes that exist
+func (data *Data) validate() error {
+	if err := data.Towers.validate(); err != nil {
+		return fmt.Errorf("tower catalog: %v", err)
+	}
+	if err := data.Weapons.validate(); err != nil {
+		return fmt.Errorf("weapon catalog: %v", err)
+	}
+	for name, spec := range data.Towers.Towers {
+		for _, sprite := range []SpriteID{spec.Sprite, spec.Barrel, spec.Bullet} {
+			if err := data.checkSprite(sprite); err != nil {
+				return fmt.Errorf("tower %s: %v", name, err)
+			}
+		}
+	}
+	for name, archetype := range data.Enemies {
+		for _, sprite := range []SpriteID{archetype.Sprite, archetype.Bullet} {
+			if err := data.checkSprite(sprite); err != nil {
+				return fmt.Errorf("enemy %s: %v", name, err)
+			}
+		}
+	}
+	for name, spec := range data.Weapons.Weapons {
+		if err := data.checkSprite(spec.Bullet); err != nil {
+			return fmt.Errorf("weapon %s: %v", name, err)
+		}
+	}
+	if err := data.checkSprite(data.Lanes.Base.Sprite); err != nil {
+		return fmt.Errorf("base: %v", err)
+	}
+	for i, wave := range data.Waves {
+		for _, group := range wave.Groups {
+			if data.Enemies[group.Enemy] == nil {
+				return fmt.Errorf("wave %d: unknown enemy %q", i+1, group.Enemy)
+			}
+			if group.Lane != "" && data.Lanes.Lanes[group.Lane] == nil {
+				return fmt.Errorf("wave %d: unknown lane %q", i+1, group.Lane)
+			}
+		}
+	}
+	return nil
+}
+
+// Hash fingerprints everything data describes, so that a replay can tell whether it is being
+// played back against the data it was recorded with
+func (data *Data) Hash() (string, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (data *Data) checkSprite(sprite SpriteID) error {
+	if _, ok := data.Sprites[sprite]; !ok {
+		return fmt.Errorf("unknown sprite %q", sprite)
+	}
+	return nil
+}
+
+// TowerSpec is one node of a tower upgrade tree: what a tower looks like and how it fights once it
+// has been built or upgraded to this spec
+type TowerSpec struct {
+	Name string `json:"-"`
+	// Level is the tower's Character.Level at this spec; upgrades go exactly one level up
+	Level  int      `json:"level"`
+	Cost   int      `json:"cost"`
+	Sprite SpriteID `json:"sprite"`
+	Barrel SpriteID `json:"barrel"`
+	Bullet SpriteID `json:"bullet"`
+	Damage int      `json:"damage"`
+	// Cooldown is the seconds between shots
+	Cooldown float64 `json:"cooldown"`
+	Range    float64 `json:"range"`
+	TurnRate float64 `json:"turnRate"`
+	// MissileTurnRate, when set, makes the tower fire missiles that home in on its target,
+	// turning up to this many degrees per second
+	MissileTurnRate float64 `json:"missileTurnRate"`
+	// Blast, when it has a radius, makes the tower's shots explode
+	Blast Blast `json:"blast"`
+	// Expire is what the tower's shots do when they run out of range
+	Expire Expiry `json:"expire"`
+	// Upgrades names the specs this one can be upgraded to
+	Upgrades []string `json:"upgrades"`
+}
+
+// TowerCatalog is the full set of tower upgrade trees
+type TowerCatalog struct {
+	// Build names the spec every new tower starts from
+	Build string `json:"build"`
+	// SellRefund is the fraction of everything spent on a tower that selling it gives back
+	SellRefund float64               `json:"sellRefund"`
+	Towers     map[string]*TowerSpec `json:"towers"`
+}
+
+func LoadTowerCatalog(filename string) (*TowerCatalog, error) {
+	catalog := &TowerCatalog{}
+	if err := loadJSON(filename, catalog); err != nil {
+		return nil, err
+	}
+	if err := catalog.validate(); err != nil {
+		return nil, fmt.Errorf("tower catalog %s: %v", filename, err)
+	}
+	return catalog, nil
+}
+
+func (catalog *TowerCatalog) validate() error {
+	if catalog.Towers[catalog.Build] == nil {
+		return fmt.Errorf("build tower %q is not defined", catalog.Build)
+	}
+	if catalog.SellRefund < 0 || catalog.SellRefund > 1 {
+		return fmt.Errorf("sell refund %v is not between 0 and 1", catalog.SellRefund)
+	}
+	for name, spec := range catalog.Towers {
+		spec.Name = name
+		if spec.Cost < 0 {
+			return fmt.Errorf("%s has a negative cost", name)
+		}
+		for _, upgrade := range spec.Upgrades {
+			next := catalog.Towers[upgrade]
+			if next == nil {
+				return fmt.Errorf("%s upgrades to undefined tower %q", name, upgrade)
+			}
+			if next.Level != spec.Level+1 {
+				return fmt.Errorf("%s is level %d but its upgrade %s is level %d", name, spec.Level, upgrade, next.Level)
+			}
+		}
+	}
+	return nil
+}
+
+// BuildSpec is the spec new towers are built from
+func (catalog *TowerCatalog) BuildSpec() *TowerSpec {
+	return catalog.Towers[catalog.Build]
+}
+
+// EnemyArchetype is one kind of enemy: how it looks, how tough it is and how it fights
+type EnemyArchetype struct {
+	Name      string   `json:"-"`
+	Sprite    SpriteID `json:"sprite"`
+	Hitpoints int      `json:"hitpoints"`
+	Strength  int      `json:"strength"`
+	// Speed is in pixels per second
+	Speed float64 `json:"speed"`
+	// Cooldown is the seconds between shots
+	Cooldown float64  `json:"cooldown"`
+	Bullet   SpriteID `json:"bullet"`
+	// FireOffset is how far ahead of the center shots leave; without one it is half the sprite's height
+	FireOffset float64 `json:"fireOffset"`
+	Reward     int     `json:"reward"`
+	// Jitter is how far off, in degrees either way, its shots may stray; 0 never misses a steady target
+	Jitter float64 `json:"jitter"`
+	// Lives is how many lives the player loses if the enemy leaks into the goal; without it, one
+	Lives int `json:"lives"`
+	// Behavior names the entry in Behaviors that drives the enemy
+	Behavior string `json:"behavior"`
+	AIParams
+}
+
+// EnemyCatalog holds every enemy archetype by name
+type EnemyCatalog map[string]*EnemyArchetype
+
+func LoadEnemyCatalog(filename string) (EnemyCatalog, error) {
+	catalog := EnemyCatalog{}
+	if err := loadJSON(filename, &catalog); err != nil {
+		return nil, err
+	}
+	for name, archetype := range catalog {
+		archetype.Name = name
+		if archetype.Hitpoints <= 0 {
+			return nil, fmt.Errorf("enemy catalog %s: %s needs positive hitpoints", filename, name)
+		}
+		if Behaviors[archetype.Behavior] == nil {
+			return nil, fmt.Errorf("enemy catalog %s: %s has unknown behavior %q", filename, name, archetype.Behavior)
+		}
+		if archetype.Lives == 0 {
+			archetype.Lives = 1
+		}
+		if archetype.Sight == 0 {
+			archetype.Sight = DefaultSight
+		}
+		if archetype.AttackRange == 0 {
+			archetype.AttackRange = DefaultAttackRange
+		}
+	}
+	return catalog, nil
+}
+
+func loadJSON(filename string, v interface{}) error {
+	infile, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer infile.Close()
+
+	if err := json.NewDecoder(infile).Decode(v); err != nil {
+		return fmt.Errorf("reading %s: %v", filename, err)
+	}
+	return nil
+}
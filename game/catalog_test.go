@@ -0,0 +1,58 @@
+package game
+
+import "testing"
+
+func TestTowerCatalogValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		catalog TowerCatalog
+		wantErr bool
+	}{
+		{"valid", TowerCatalog{Build: "gun", SellRefund: 0.5, Towers: map[string]*TowerSpec{
+			"gun":   {Level: 1, Cost: 100, Upgrades: []string{"heavy"}},
+			"heavy": {Level: 2, Cost: 150},
+		}}, false},
+		{"free", TowerCatalog{Build: "gun", Towers: map[string]*TowerSpec{"gun": {Level: 1}}}, false},
+		{"negative cost", TowerCatalog{Build: "gun", Towers: map[string]*TowerSpec{"gun": {Level: 1, Cost: -50}}}, true},
+		{"negative upgrade cost", TowerCatalog{Build: "gun", Towers: map[string]*TowerSpec{
+			"gun":   {Level: 1, Cost: 100, Upgrades: []string{"heavy"}},
+			"heavy": {Level: 2, Cost: -150},
+		}}, true},
+		{"no build tower", TowerCatalog{Build: "gun", Towers: map[string]*TowerSpec{}}, true},
+		{"refund over 1", TowerCatalog{Build: "gun", SellRefund: 1.5, Towers: map[string]*TowerSpec{"gun": {Level: 1}}}, true},
+		{"undefined upgrade", TowerCatalog{Build: "gun", Towers: map[string]*TowerSpec{
+			"gun": {Level: 1, Upgrades: []string{"heavy"}},
+		}}, true},
+		{"upgrade skips a level", TowerCatalog{Build: "gun", Towers: map[string]*TowerSpec{
+			"gun":   {Level: 1, Upgrades: []string{"heavy"}},
+			"heavy": {Level: 3},
+		}}, true},
+	}
+	for _, test := range tests {
+		if err := test.catalog.validate(); (err != nil) != test.wantErr {
+			t.Errorf("%s: validate error = %v, want error %v", test.name, err, test.wantErr)
+		}
+	}
+}
+
+func TestNewGameRejectsBadData(t *testing.T) {
+	tests := []struct {
+		name  string
+		spoil func(data *Data)
+	}{
+		{"undefined primary weapon", func(data *Data) { data.Weapons.Primary = "nothing" }},
+		{"undefined secondary weapon", func(data *Data) { data.Weapons.Secondary = append(data.Weapons.Secondary, "nothing") }},
+		{"undefined build tower", func(data *Data) { data.Towers.Build = "nothing" }},
+		{"negative tower cost", func(data *Data) { data.Towers.BuildSpec().Cost = -1 }},
+		{"unknown enemy", func(data *Data) { data.Waves[0].Groups[0].Enemy = "nothing" }},
+		{"unknown lane", func(data *Data) { data.Waves[0].Groups[0].Lane = "nothing" }},
+		{"unknown sprite", func(data *Data) { data.Lanes.Base.Sprite = "nothing" }},
+	}
+	for _, test := range tests {
+		data := loadTestData(t)
+		test.spoil(data)
+		if _, err := NewGame(1920, 1080, data, 1); err == nil {
+			t.Errorf("%s: NewGame accepted it", test.name)
+		}
+	}
+}
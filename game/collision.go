@@ -0,0 +1,198 @@
+package game
+
+import "math"
+
+type ShapeKind int
+
+const (
+	// Box is an axis aligned rectangle that ignores the entity's facing
+	Box ShapeKind = iota
+	// OrientedBox is a rectangle turned to match the entity's facing, the way it is drawn
+	OrientedBox
+	// Circle fits inside the smaller side of the entity
+	Circle
+)
+
+// Shape is a collision volume in world space. Boxes extend Extents.X either side of Center
+// along Axis and Extents.Y along Axis.Perp(); an axis aligned Box always has Axis {1, 0}.
+type Shape struct {
+	Kind    ShapeKind
+	Center  Vec
+	Axis    Vec
+	Extents Vec
+	Radius  float64
+}
+
+// Dimensional is anything that can collide; GetShape reports its current collision volume
+type Dimensional interface {
+	GetShape() Shape
+}
+
+// shape builds the entity's collision volume of the given kind. Sprite images point down,
+// so the image's height lies along facing and its width across it.
+func (entity *Entity) shape(kind ShapeKind, facing Vec) Shape {
+	w, h := float64(entity.W), float64(entity.H)
+	switch kind {
+	case OrientedBox:
+		if facing == (Vec{}) {
+			facing = Vec{0, 1}
+		}
+		return Shape{Kind: OrientedBox, Center: entity.Vec, Axis: facing, Extents: Vec{h / 2, w / 2}}
+	case Circle:
+		return Shape{Kind: Circle, Center: entity.Vec, Radius: math.Min(w, h) / 2}
+	default:
+		return Shape{Kind: Box, Center: entity.Vec, Axis: Vec{1, 0}, Extents: Vec{w / 2, h / 2}}
+	}
+}
+
+// Bullet, Player, Enemy and Base implement Dimensional
+func (bullet *Bullet) GetShape() Shape {
+	return bullet.shape(bullet.Collider, bullet.Facing)
+}
+
+func (player *Player) GetShape() Shape {
+	return player.shape(player.Collider, player.Facing)
+}
+
+func (enemy *Enemy) GetShape() Shape {
+	return enemy.shape(enemy.Collider, enemy.Facing)
+}
+
+func (base *Base) GetShape() Shape {
+	return base.shape(base.Collider, base.Facing)
+}
+
+func CheckCollision(obj1, obj2 Dimensional) bool {
+	return Overlaps(obj1.GetShape(), obj2.GetShape())
+}
+
+// Overlaps reports whether two shapes intersect, touching edges included
+func Overlaps(a, b Shape) bool {
+	switch {
+	case a.Kind == Circle && b.Kind == Circle:
+		return a.Center.Dist(b.Center) <= a.Radius+b.Radius
+	case a.Kind == Circle:
+		return circleOverlapsBox(a, b)
+	case b.Kind == Circle:
+		return circleOverlapsBox(b, a)
+	case a.Kind == Box && b.Kind == Box:
+		return math.Abs(a.Center.X-b.Center.X) <= a.Extents.X+b.Extents.X &&
+			math.Abs(a.Center.Y-b.Center.Y) <= a.Extents.Y+b.Extents.Y
+	default:
+		return boxesOverlap(a, b)
+	}
+}
+
+// boxesOverlap is the separating axis test for two rectangles: they are apart exactly
+// when their projections onto one of the four edge normals do not overlap
+func boxesOverlap(a, b Shape) bool {
+	d := b.Center.Sub(a.Center)
+	for _, axis := range [4]Vec{a.Axis, a.Axis.Perp(), b.Axis, b.Axis.Perp()} {
+		if math.Abs(d.Dot(axis)) > a.projectedRadius(axis)+b.projectedRadius(axis) {
+			return false
+		}
+	}
+	return true
+}
+
+// projectedRadius is half the length of the box's shadow on axis
+func (box Shape) projectedRadius(axis Vec) float64 {
+	return box.Extents.X*math.Abs(box.Axis.Dot(axis)) + box.Extents.Y*math.Abs(box.Axis.Perp().Dot(axis))
+}
+
+func circleOverlapsBox(circle, box Shape) bool {
+	return box.DistanceTo(circle.Center) <= circle.Radius
+}
+
+// DistanceTo is how far p is from the nearest point of the shape, or 0 if p is inside it
+func (shape Shape) DistanceTo(p Vec) float64 {
+	if shape.Kind == Circle {
+		return math.Max(0, shape.Center.Dist(p)-shape.Radius)
+	}
+	local := shape.toLocal(p)
+	closest := Vec{clamp(local.X, -shape.Extents.X, shape.Extents.X), clamp(local.Y, -shape.Extents.Y, shape.Extents.Y)}
+	return local.Dist(closest)
+}
+
+// toLocal expresses the world point p in the box's frame, with the box's center at the origin
+func (box Shape) toLocal(p Vec) Vec {
+	d := p.Sub(box.Center)
+	return Vec{d.Dot(box.Axis), d.Dot(box.Axis.Perp())}
+}
+
+// Bounds is the smallest axis aligned rectangle containing the shape
+func (shape Shape) Bounds() (min, max Vec) {
+	var half Vec
+	if shape.Kind == Circle {
+		half = Vec{shape.Radius, shape.Radius}
+	} else {
+		half = Vec{shape.projectedRadius(Vec{1, 0}), shape.projectedRadius(Vec{0, 1})}
+	}
+	return shape.Center.Sub(half), shape.Center.Add(half)
+}
+
+func clamp(value, min, max float64) float64 {
+	return math.Max(min, math.Min(max, value))
+}
+
+// Raycast sweeps a circle of the given radius from from to to and reports the fraction of
+// the way along, between 0 and 1, at which it first touches target. A circle that starts
+// out touching target hits at 0. Boxes are grown by radius on every side, so a sweep past
+// a box corner can hit slightly early.
+func Raycast(from, to Vec, radius float64, target Shape) (float64, bool) {
+	if target.Kind == Circle {
+		return raycastCircle(from, to, target.Center, target.Radius+radius)
+	}
+	return raycastBox(from, to, radius, target)
+}
+
+func raycastCircle(from, to, center Vec, radius float64) (float64, bool) {
+	d := to.Sub(from)
+	f := from.Sub(center)
+	c := f.Dot(f) - radius*radius
+	if c <= 0 {
+		return 0, true
+	}
+	a := d.Dot(d)
+	if a == 0 {
+		return 0, false
+	}
+	b := 2 * f.Dot(d)
+	disc := b*b - 4*a*c
+	if disc < 0 {
+		return 0, false
+	}
+	t := (-b - math.Sqrt(disc)) / (2 * a)
+	if t < 0 || t > 1 {
+		return 0, false
+	}
+	return t, true
+}
+
+// raycastBox clips the segment against the box's two pairs of parallel edges in the box's frame
+func raycastBox(from, to Vec, radius float64, box Shape) (float64, bool) {
+	p := box.toLocal(from)
+	d := box.toLocal(to).Sub(p)
+	extents := box.Extents.Add(Vec{radius, radius})
+	tMin, tMax := 0.0, 1.0
+	for _, axis := range [2][3]float64{{p.X, d.X, extents.X}, {p.Y, d.Y, extents.Y}} {
+		start, delta, extent := axis[0], axis[1], axis[2]
+		if delta == 0 {
+			if math.Abs(start) > extent {
+				return 0, false
+			}
+			continue
+		}
+		t1 := (-extent - start) / delta
+		t2 := (extent - start) / delta
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		tMin = math.Max(tMin, t1)
+		tMax = math.Min(tMax, t2)
+		if tMin > tMax {
+			return 0, false
+		}
+	}
+	return tMin, true
+}
@@ -0,0 +1,109 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+func box(x, y, w, h float64) Shape {
+	return Shape{Kind: Box, Center: Vec{x, y}, Axis: Vec{1, 0}, Extents: Vec{w / 2, h / 2}}
+}
+
+func orientedBox(x, y, w, h, degrees float64) Shape {
+	rad := DegreeToRad(degrees)
+	return Shape{Kind: OrientedBox, Center: Vec{x, y}, Axis: Vec{math.Cos(rad), math.Sin(rad)}, Extents: Vec{w / 2, h / 2}}
+}
+
+func circle(x, y, r float64) Shape {
+	return Shape{Kind: Circle, Center: Vec{x, y}, Radius: r}
+}
+
+func TestOverlaps(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Shape
+		want bool
+	}{
+		{"boxes apart", box(0, 0, 10, 10), box(20, 0, 10, 10), false},
+		{"boxes overlapping", box(0, 0, 10, 10), box(8, 8, 10, 10), true},
+		{"boxes touching edges", box(0, 0, 10, 10), box(10, 0, 10, 10), true},
+		{"box inside box", box(0, 0, 100, 100), box(5, 5, 2, 2), true},
+		{"circles apart", circle(0, 0, 5), circle(11, 0, 5), false},
+		{"circles touching", circle(0, 0, 5), circle(10, 0, 5), true},
+		{"circle touching box", circle(0, 0, 5), box(10, 0, 10, 10), true},
+		{"circle short of box", circle(0, 0, 5), box(11, 0, 10, 10), false},
+		{"circle past box corner", circle(0, 0, 5), box(9, 9, 10, 10), false},
+		{"circle near box corner", circle(0, 0, 5), box(7, 7, 10, 10), true},
+		{"box then circle", box(9, 0, 10, 10), circle(0, 0, 5), true},
+		{"circle inside rotated box", circle(0, 0, 1), orientedBox(0, 0, 40, 4, 45), true},
+		{"rotated box clears corner", orientedBox(0, 0, 20, 2, 45), box(9, -9, 6, 6), false},
+		{"rotated box reaches along its axis", orientedBox(0, 0, 40, 2, 45), box(12, 12, 6, 6), true},
+	}
+	for _, test := range tests {
+		if got := Overlaps(test.a, test.b); got != test.want {
+			t.Errorf("%s: Overlaps = %v, want %v", test.name, got, test.want)
+		}
+		if got := Overlaps(test.b, test.a); got != test.want {
+			t.Errorf("%s: Overlaps reversed = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestBoxesOverlap(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Shape
+		want bool
+	}{
+		{"aligned apart", box(0, 0, 10, 10), box(20, 0, 10, 10), false},
+		{"aligned overlapping", box(0, 0, 10, 10), box(5, 5, 10, 10), true},
+		// The bounding boxes of these two overlap, but a diagonal gap separates them
+		{"diagonal gap", orientedBox(0, 0, 30, 4, 45), orientedBox(12, -4, 30, 4, 45), false},
+		{"crossing", orientedBox(0, 0, 30, 4, 45), orientedBox(0, 0, 30, 4, -45), true},
+		{"rotated corner into box", orientedBox(0, 0, 10, 10, 45), box(11, 0, 10, 10), true},
+		{"rotated corner short of box", orientedBox(0, 0, 10, 10, 45), box(13, 0, 10, 10), false},
+	}
+	for _, test := range tests {
+		if got := boxesOverlap(test.a, test.b); got != test.want {
+			t.Errorf("%s: boxesOverlap = %v, want %v", test.name, got, test.want)
+		}
+		if got := boxesOverlap(test.b, test.a); got != test.want {
+			t.Errorf("%s: boxesOverlap reversed = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRaycast(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to Vec
+		radius   float64
+		target   Shape
+		wantHit  bool
+		wantT    float64
+	}{
+		{"straight into circle", Vec{0, 0}, Vec{100, 0}, 0, circle(50, 0, 10), true, 0.4},
+		{"swept circle into circle", Vec{0, 0}, Vec{100, 0}, 5, circle(50, 0, 10), true, 0.35},
+		{"past circle", Vec{0, 0}, Vec{100, 0}, 0, circle(50, 20, 10), false, 0},
+		{"stops short of circle", Vec{0, 0}, Vec{30, 0}, 0, circle(50, 0, 10), false, 0},
+		{"starts inside circle", Vec{50, 0}, Vec{100, 0}, 0, circle(50, 0, 10), true, 0},
+		{"no movement", Vec{0, 0}, Vec{0, 0}, 0, circle(50, 0, 10), false, 0},
+		{"straight into box", Vec{0, 0}, Vec{100, 0}, 0, box(50, 0, 20, 20), true, 0.4},
+		{"swept circle into box", Vec{0, 0}, Vec{100, 0}, 5, box(50, 0, 20, 20), true, 0.35},
+		{"past box", Vec{0, 0}, Vec{100, 0}, 0, box(50, 20, 20, 20), false, 0},
+		{"grazing swept circle", Vec{0, 0}, Vec{100, 0}, 11, box(50, 20, 20, 20), true, 0.29},
+		{"from behind box", Vec{100, 0}, Vec{0, 0}, 0, box(50, 0, 20, 20), true, 0.4},
+		{"into rotated box", Vec{0, 0}, Vec{100, 0}, 0, orientedBox(50, 0, 20, 20, 90), true, 0.4},
+		{"starts inside box", Vec{50, 0}, Vec{100, 0}, 0, box(50, 0, 20, 20), true, 0},
+	}
+	for _, test := range tests {
+		at, hit := Raycast(test.from, test.to, test.radius, test.target)
+		if hit != test.wantHit {
+			t.Errorf("%s: hit = %v, want %v", test.name, hit, test.wantHit)
+			continue
+		}
+		if hit && math.Abs(at-test.wantT) > 1e-9 {
+			t.Errorf("%s: t = %v, want %v", test.name, at, test.wantT)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package game
+
+import "math"
+
+// BlastTicks is how long a blast's explosion animation plays
+const BlastTicks = 24
+
+// Blast makes a projectile explode where it lands, damaging everything within Radius of the
+// point of impact instead of only what it struck
+type Blast struct {
+	Radius float64 `json:"radius"`
+	// Falloff is how much of the damage is lost at the edge of the blast, from 0 for none to 1 for all
+	// of it, shrinking linearly with distance from the center
+	Falloff float64 `json:"falloff"`
+	// HitsPlayer lets the player's own blasts hurt the player
+	HitsPlayer bool `json:"hitsPlayer"`
+}
+
+// Detonation is a blast going off, kept around while its explosion animation plays
+type Detonation struct {
+	Vec
+	Radius  float64
+	Counter int
+}
+
+// damageAt is how much of damage reaches something whose nearest point is dist away from the center of the blast
+func (blast Blast) damageAt(damage int, dist float64) int {
+	if dist >= blast.Radius {
+		return 0
+	}
+	scale := 1 - blast.Falloff*dist/blast.Radius
+	return int(math.Round(float64(damage) * scale))
+}
+
+// Explode sets off blast at center, damaging every unit it reaches. Blasts fired by enemies
+// only hurt the player; everyone else's hurt enemies, and the player too if HitsPlayer is set.
+func (level *Level) Explode(center Vec, damage int, blast Blast, firedByEnemy bool) {
+	for _, unit := range level.Units.QueryRadius(center, blast.Radius) {
+		switch unit := unit.(type) {
+		case *Enemy:
+			if firedByEnemy || unit.IsDestroyed {
+				continue
+			}
+			level.damageEnemy(unit, blast.damageAt(damage, unit.GetShape().DistanceTo(center)))
+		case *Player:
+			if firedByEnemy || blast.HitsPlayer {
+				level.damagePlayer(blast.damageAt(damage, unit.GetShape().DistanceTo(center)))
+			}
+		case *Base:
+			if firedByEnemy && !unit.IsDestroyed {
+				level.damageBase(blast.damageAt(damage, unit.GetShape().DistanceTo(center)))
+			}
+		}
+	}
+	level.Detonations = append(level.Detonations, &Detonation{center, blast.Radius, 0})
+}
+
+func (level *Level) updateDetonations() {
+	detonationIndex := 0
+	for _, detonation := range level.Detonations {
+		detonation.Counter++
+		if detonation.Counter < BlastTicks {
+			level.Detonations[detonationIndex] = detonation
+			detonationIndex++
+		}
+	}
+	level.Detonations = level.Detonations[:detonationIndex]
+}
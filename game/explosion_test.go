@@ -0,0 +1,96 @@
+package game
+
+import "testing"
+
+func TestBlastDamageAt(t *testing.T) {
+	tests := []struct {
+		name   string
+		blast  Blast
+		damage int
+		dist   float64
+		want   int
+	}{
+		{"center", Blast{Radius: 100, Falloff: 0.5}, 40, 0, 40},
+		{"halfway", Blast{Radius: 100, Falloff: 0.5}, 40, 50, 30},
+		{"near the edge", Blast{Radius: 100, Falloff: 0.5}, 40, 99, 20},
+		{"edge", Blast{Radius: 100, Falloff: 0.5}, 40, 100, 0},
+		{"beyond", Blast{Radius: 100, Falloff: 0.5}, 40, 150, 0},
+		{"no falloff", Blast{Radius: 100}, 40, 90, 40},
+		{"full falloff", Blast{Radius: 100, Falloff: 1}, 40, 50, 20},
+		{"rounds", Blast{Radius: 100, Falloff: 1}, 10, 35, 7},
+	}
+	for _, test := range tests {
+		if got := test.blast.damageAt(test.damage, test.dist); got != test.want {
+			t.Errorf("%s: damageAt = %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestExplode(t *testing.T) {
+	tests := []struct {
+		name         string
+		blast        Blast
+		firedByEnemy bool
+		// The damage each unit should take
+		near, far, player, base int
+	}{
+		{"player's blast", Blast{Radius: 100, Falloff: 0.5}, false, 40, 0, 0, 0},
+		{"player's blast that hits the player", Blast{Radius: 100, Falloff: 0.5, HitsPlayer: true}, false, 40, 0, 40, 0},
+		{"enemy's blast", Blast{Radius: 100, Falloff: 0.5}, true, 0, 0, 40, 40},
+	}
+	for _, test := range tests {
+		game := newTestGame(t, 1)
+		level := game.Level
+		center := level.Player.Vec
+		level.Base.Vec = center
+		near, err := level.SpawnEnemy("heavy", &center)
+		if err != nil {
+			t.Fatal(err)
+		}
+		farAt := center.Add(Vec{500, 0})
+		far, err := level.SpawnEnemy("heavy", &farAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		player, base := level.Player.Hitpoints, level.Base.Hitpoints
+		level.indexUnits()
+
+		level.Explode(center, 40, test.blast, test.firedByEnemy)
+		if got := near.MaxHitpoints - near.Hitpoints; got != test.near {
+			t.Errorf("%s: enemy at the center took %d, want %d", test.name, got, test.near)
+		}
+		if got := far.MaxHitpoints - far.Hitpoints; got != test.far {
+			t.Errorf("%s: enemy out of reach took %d, want %d", test.name, got, test.far)
+		}
+		if got := player - level.Player.Hitpoints; got != test.player {
+			t.Errorf("%s: player took %d, want %d", test.name, got, test.player)
+		}
+		if got := base - level.Base.Hitpoints; got != test.base {
+			t.Errorf("%s: base took %d, want %d", test.name, got, test.base)
+		}
+		if len(level.Detonations) != 1 || level.Detonations[0].Vec != center {
+			t.Errorf("%s: detonations are %v, want one at %v", test.name, level.Detonations, center)
+		}
+	}
+}
+
+func TestExplodeFalloffReachesUnitsByTheirNearestPoint(t *testing.T) {
+	game := newTestGame(t, 1)
+	level := game.Level
+	at := level.Player.Vec.Add(Vec{2000, 0})
+	enemy, err := level.SpawnEnemy("heavy", &at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	level.indexUnits()
+	blast := Blast{Radius: 200, Falloff: 1}
+	center := at.Add(Vec{150, 0})
+	want := blast.damageAt(100, enemy.GetShape().DistanceTo(center))
+	if want <= 0 || want >= 100 {
+		t.Fatalf("enemy is not partway into the blast; it would take %d", want)
+	}
+	level.Explode(center, 100, blast, false)
+	if got := enemy.MaxHitpoints - enemy.Hitpoints; got != want {
+		t.Errorf("enemy took %d, want %d", got, want)
+	}
+}
@@ -1,23 +1,76 @@
 package game
 
 import (
-	"github.com/veandco/go-sdl2/sdl"
+	"fmt"
 	"math"
-	"os"
+	"math/rand"
+	"time"
+)
+
+// DefaultTickRate is the number of simulation steps Run performs per second
+const DefaultTickRate = 60
+
+// DefaultLevelName names the only level there is so far, the open sandbox
+const DefaultLevelName = "sandbox"
+
+// Animation lengths, in ticks, that gate when bullets and enemies are removed from the level
+const (
+	BulletFlashTicks   = 5
+	BulletExplodeTicks = 5
+	EnemyExplodeTicks  = 24
 )
 
 type Game struct {
-	InputChan chan *Input
-	LevelChan chan *Level
-	Level     *Level
+	InputChan    chan *Input
+	SnapshotChan chan *Snapshot
+	Level        *Level
+	TickRate     int
+	Seed         int64
+	// DataHash fingerprints the data the game was created from
+	DataHash string
+	// Recording, when set, receives every input the simulation applies
+	Recording     *Replay
+	playback      *Replay
+	playbackIndex int
+	// published is the number of ledger events the renderer has been handed in snapshots so far
+	published int
 }
 
 type Level struct {
-	Player             *Player
-	Enemies            []*Enemy
-	Bullets            []*Bullet
-	PrimaryFirePressed bool
-	EnemySpawnTimer    int
+	Name    string
+	Tick    int
+	Map     *TileMap
+	Player  *Player
+	Enemies []*Enemy
+	Bullets []*Bullet
+	Towers  []*Tower
+	// Detonations are the blasts whose explosions are still playing
+	Detonations []*Detonation
+	// Units indexes the player and every live enemy by position; it is rebuilt each tick
+	Units *SpatialHash
+	Waves *WaveDirector
+	// Lanes are the roads enemies may drive along to the goal, by name
+	Lanes map[string]*Lane
+	// Lives is how many more enemies may leak into the goal
+	Lives int
+	// Base is the headquarters the player defends
+	Base *Base
+	// Outcome is set once the level is won or lost, after which it no longer changes
+	Outcome Outcome
+	View    Size
+	// Camera is the world position of the top left corner of the view
+	Camera Vec
+	// BuildMode turns primary fire into placing a tower on the tile under the cursor
+	BuildMode bool
+	// Ledger holds the player's currency
+	Ledger        *Ledger
+	Sprites       SpriteMeta
+	TowerCatalog  *TowerCatalog
+	EnemyCatalog  EnemyCatalog
+	WeaponCatalog *WeaponCatalog
+	// Rand is the only source of randomness the simulation may use, so that a seed reproduces a session
+	Rand                                         *rand.Rand
+	topBound, bottomBound, leftBound, rightBound float64
 }
 
 type InputType int
@@ -32,12 +85,20 @@ const (
 	FirePrimary
 	FireSecondary
 	Pause
+	Aim
+	ToggleBuild
+	CycleTargetPolicy
+	UpgradeTower
+	SellTower
+	SelectSecondary
 )
 
 type Input struct {
 	Pos
 	Type    InputType
 	Pressed bool
+	// Option picks between alternatives, such as which branch of an upgrade tree to take
+	Option int
 }
 
 type Pos struct {
@@ -48,19 +109,22 @@ type Size struct {
 	W, H int
 }
 
+// Vel and Speed are in pixels per second. Facing is a unit vector along which the entity
+// is drawn and fires, which need not be the way it is moving.
 type Velocity struct {
-	Xvel, Yvel int
-	Direction  float64
-	Speed      float64
+	Vel    Vec
+	Facing Vec
+	Speed  float64
 }
 
+// Entity positions are world coordinates of the entity's center
 type Entity struct {
-	Pos
+	Vec
 	Size
-	TextureName string
-	Texture     *sdl.Texture
-	FireOffsetX int
-	FireOffsetY int
+	Sprite SpriteID
+	// FireOffset is how far ahead of the center, along Facing, shots leave the entity
+	FireOffset float64
+	Collider   ShapeKind
 }
 
 type Character struct {
@@ -73,35 +137,92 @@ type Character struct {
 	DestroyedAnimationPlayed  bool
 	DestroyedAnimationCounter int
 	IsDestroyed               bool
-	FireRateTimer             int
-	FireRateResetValue        int
 	IsFiring                  bool
+	BulletSprite              SpriteID
+}
+
+// FireControl paces the shots of something that fires on its own, rather than through a weapon slot.
+// FireTimer counts the seconds since its last shot, up to FireCooldown.
+type FireControl struct {
+	FireTimer    float64
+	FireCooldown float64
 }
 
-type Dimensional interface {
-	GetDimensionalData() (int, int, int, int)
+// Update counts dt seconds toward the next shot
+func (control *FireControl) Update(dt float64) {
+	control.FireTimer = math.Min(control.FireTimer+dt, control.FireCooldown)
 }
 
 type Shooter interface {
-	// Should return FireRateTimer, FireRateResetValue, and whether the entity is the player
-	GetFireSettings() (int, int, bool)
-	SetFireTimer(int)
+	// Should return FireTimer, FireCooldown, and whether the entity fights on the player's side
+	GetFireSettings() (float64, float64, bool)
+	SetFireTimer(float64)
 	GetSelf() *Character
 }
 
 type Player struct {
 	Character
-	Currency                         int
-	AtTop, AtBottom, AtLeft, AtRight bool
+	// Cursor is the mouse position on screen, and Aim the world point under it
+	Cursor  Vec
+	Aim     Vec
+	Primary *WeaponSlot
+	// Secondary holds the secondary weapons carried, of which Selected is the one in hand
+	Secondary []*WeaponSlot
+	Selected  int
+	// Moving holds -1, 0 or 1 per axis for the movement keys currently held
+	Moving Vec
 }
 
 type Enemy struct {
 	Character
+	FireControl
+	// Archetype names the kind of enemy this is in the enemy catalog
+	Archetype string
+	Behavior  Behavior
+	State     AIState
+	AI        AIParams
+	// MaxHitpoints is what the enemy spawned with
+	MaxHitpoints int
+	// Home is where the enemy spawned, which it patrols around and retreats to
+	Home Vec
+	// LastHitTick is the tick the enemy was last struck by a shot, or 0 if it never has been
+	LastHitTick int
+	// Reward is what the player earns for destroying the enemy
+	Reward int
+	// Jitter is how far off, in degrees either way, the enemy's shots may stray from its aim
+	Jitter float64
+	// Lane is the lane the enemy drives along toward the goal, if it spawned on one
+	Lane *Lane
+	// LivesCost is how many lives the player loses if the enemy leaks into the goal
+	LivesCost int
+	// Leaked is set once the enemy has reached the goal
+	Leaked bool
+	// Target is what the enemy aims at while it is firing: the player or the base
+	Target *Character
+	// Path holds the tiles the enemy still has to drive through to reach its goal, or nil if it has no route
+	Path        []Pos
+	pathGoal    Pos
+	replanTimer int
+	patrolIndex int
+	laneIndex   int
 }
 
 type Bullet struct {
 	Entity
 	Velocity
+	// Prev is where the bullet was before its last move; it can hit anything between there and now
+	Prev Vec
+	// TurnRate, when positive, makes the bullet a missile that steers toward Target by up to
+	// this many degrees per second. It loses its lock and flies straight once Target is destroyed.
+	TurnRate float64
+	Target   *Enemy
+	Blast    Blast
+	// Range is how far, in pixels, and Lifetime how long, in seconds, the bullet may fly before it
+	// expires in the way Expire says. Travelled and Age measure how much of each it has used.
+	Range, Travelled       float64
+	Lifetime, Age          float64
+	Expire                 Expiry
+	Expired                bool
 	FiredBy                *Character
 	FiredByEnemy           bool
 	Damage                 int
@@ -112,295 +233,594 @@ type Bullet struct {
 	IsColliding            bool
 }
 
-// Player and Enemy implement Shooter
-func (player *Player) GetFireSettings() (int, int, bool) {
-	return player.FireRateTimer, player.FireRateResetValue, true
-}
-
-func (player *Player) SetFireTimer(value int) {
-	player.FireRateTimer = value
+// Enemy and Tower implement Shooter
+func (enemy *Enemy) GetFireSettings() (float64, float64, bool) {
+	return enemy.FireTimer, enemy.FireCooldown, false
 }
 
-func (player *Player) GetSelf() *Character {
-	return &player.Character
-}
-
-func (enemy *Enemy) GetFireSettings() (int, int, bool) {
-	return enemy.FireRateTimer, enemy.FireRateResetValue, false
-}
-
-func (enemy *Enemy) SetFireTimer(value int) {
-	enemy.FireRateTimer = value
+func (enemy *Enemy) SetFireTimer(value float64) {
+	enemy.FireTimer = value
 }
 
 func (enemy *Enemy) GetSelf() *Character {
 	return &enemy.Character
 }
 
-// Bullet, Player, and Enemy implement Dimensional
-func (bullet *Bullet) GetDimensionalData() (int, int, int, int) {
-	return bullet.X, bullet.Y, bullet.W, bullet.H
-}
-
-func (player *Player) GetDimensionalData() (int, int, int, int) {
-	return player.X, player.Y, player.W, player.H
-}
-
-func (enemy *Enemy) GetDimensionalData() (int, int, int, int) {
-	return enemy.X, enemy.Y, enemy.W, enemy.H
-}
-
-func getObjMinMax(obj Dimensional) (int, int, int, int) {
-	x, y, w, h := obj.GetDimensionalData()
-	xMin := x - w/2
-	xMax := x + w/2
-	yMin := y - h/2
-	yMax := y + h/2
-	return xMin, yMin, xMax, yMax
-}
-
-func CheckCollision(obj1, obj2 Dimensional) bool {
-	obj1MinX, obj1MinY, obj1MaxX, obj1MaxY := getObjMinMax(obj1)
-	obj2MinX, obj2MinY, obj2MaxX, obj2MaxY := getObjMinMax(obj2)
-	if obj2MinX >= obj1MinX && obj2MinX <= obj1MaxX && obj2MinY >= obj1MinY && obj2MinY <= obj1MaxY {
-		return true
-	}
-	if obj2MinX >= obj1MinX && obj2MinX <= obj1MaxX && obj2MaxY >= obj1MinY && obj2MaxY <= obj1MaxY {
-		return true
-	}
-	if obj2MaxX >= obj1MinX && obj2MaxX <= obj1MaxX && obj2MinY >= obj1MinY && obj2MinY <= obj1MaxY {
-		return true
-	}
-	if obj2MaxX >= obj1MinX && obj2MaxX <= obj1MaxX && obj2MaxY >= obj1MinY && obj2MaxY <= obj1MaxY {
-		return true
-	}
-	return false
-}
-
-func (level *Level) InitBullet(texName string) *Bullet {
+func (level *Level) InitBullet(sprite SpriteID, firedBy *Character) *Bullet {
 	bullet := &Bullet{}
-	bullet.TextureName = texName
-	bullet.Speed = 10.0
-	//bullet.Texture = tex
+	bullet.Sprite = sprite
+	bullet.Speed = DefaultBulletSpeed
 	bullet.FlashCounter = 0
 	bullet.FireAnimationPlayed = false
 	bullet.DestroyAnimationPlayed = false
 	bullet.Damage = 0
 	bullet.IsColliding = false
-	//bullet.W = int(w)
-	//bullet.H = int(h)
-	//bullet.Direction = level.Player.Direction
-	//bullet.X = (level.Player.X + level.Player.FireOffsetX) - bullet.W/2
-	//bullet.Y = (level.Player.Y + level.Player.FireOffsetY) - bullet.H/2
+	bullet.Range = DefaultBulletRange
+	bullet.Lifetime = DefaultBulletLifetime
+	bullet.Expire = ExpireFizzle
+	bullet.Size = level.Sprites[sprite]
+	bullet.Collider = OrientedBox
+	bullet.FiredBy = firedBy
+	bullet.Facing = firedBy.Facing
+	bullet.Vec = firedBy.Muzzle()
+	bullet.Prev = bullet.Vec
+	bullet.Vel = bullet.Facing.Scale(bullet.Speed)
 	return bullet
 }
 
 func (level *Level) initPlayer() {
 	player := &Player{}
-	player.TextureName = "tank_huge"
+	player.Sprite = "tank_huge"
 	player.IsDestroyed = false
 	player.Hitpoints = 100
 	player.Strength = 10
-	player.Speed = 1.0
-	player.FireRateTimer = 0
-	player.FireRateResetValue = 50
-	player.AtBottom = false
-	player.AtLeft = false
-	player.AtRight = false
-	player.AtTop = false
-	//player.W = int(w)
-	//player.H = int(h)
-	//player.X = ui.WinWidth/2 - player.W/2
-	//player.Y = ui.WinHeight/2 - player.H/2
-
-	//player.Texture = tex
+	player.Speed = 600.0
+	player.Primary = NewWeaponSlot(level.WeaponCatalog.Weapons[level.WeaponCatalog.Primary])
+	for _, name := range level.WeaponCatalog.Secondary {
+		player.Secondary = append(player.Secondary, NewWeaponSlot(level.WeaponCatalog.Weapons[name]))
+	}
+	player.Selected = 0
+	player.Size = level.Sprites[player.Sprite]
+	player.Collider = OrientedBox
+	player.Vec = Vec{float64(level.View.W) / 2, float64(level.View.H) / 2}
+	player.Facing = Vec{0, 1}
+	player.FireOffset = float64(player.H) / 2
+	player.Cursor = player.Vec.Sub(level.Camera).Add(player.Facing)
+	player.Aim = player.Vec.Add(player.Facing)
 	level.Player = player
 }
 
-func (level *Level) InitEnemy() *Enemy {
+func (level *Level) InitEnemy(archetype *EnemyArchetype) *Enemy {
 	enemy := &Enemy{}
-	enemy.TextureName = "tank_dark"
+	enemy.Archetype = archetype.Name
+	enemy.Sprite = archetype.Sprite
 	enemy.IsDestroyed = false
-	enemy.Hitpoints = 50
-	enemy.Strength = 5
-	enemy.Speed = 1.0
-	enemy.FireRateTimer = 0
-	enemy.FireRateResetValue = 100
-	//enemy.W = int(w)
-	//enemy.H = int(h)
-	enemy.X = 300
-	enemy.Y = 300
-	//enemy.FireOffsetX = enemy.W / 2
-	//enemy.FireOffsetY = enemy.H / 2
-	//enemy.Texture = tex
+	enemy.Hitpoints = archetype.Hitpoints
+	enemy.MaxHitpoints = archetype.Hitpoints
+	enemy.Strength = archetype.Strength
+	enemy.Speed = archetype.Speed
+	enemy.FireTimer = 0
+	enemy.FireCooldown = archetype.Cooldown
+	enemy.BulletSprite = archetype.Bullet
+	enemy.Reward = archetype.Reward
+	enemy.Jitter = archetype.Jitter
+	enemy.LivesCost = archetype.Lives
+	enemy.Behavior = Behaviors[archetype.Behavior]
+	enemy.State = enemy.Behavior.Start()
+	enemy.AI = archetype.AIParams
+	enemy.Size = level.Sprites[enemy.Sprite]
+	enemy.Collider = OrientedBox
+	enemy.Vec = level.Camera.Add(level.randomEdgePos(enemy.Size))
+	enemy.Home = enemy.Vec
+	enemy.Facing = Vec{0, 1}
+	enemy.FireOffset = archetype.FireOffset
+	if enemy.FireOffset == 0 {
+		enemy.FireOffset = float64(enemy.H) / 2
+	}
 	return enemy
 }
 
-func (bullet *Bullet) Update() {
-	if !bullet.IsColliding {
-		bulletDirRad := DegreeToRad(bullet.Direction + 90)
-		nextX, nextY := findNextPointInTravel(bullet.Speed, bulletDirRad)
-		bullet.X += nextX
-		bullet.Y += nextY
+// SpawnEnemy adds an enemy of the named archetype to the level at the world point at, or
+// along a random edge of the view if at is nil
+func (level *Level) SpawnEnemy(name string, at *Vec) (*Enemy, error) {
+	archetype := level.EnemyCatalog[name]
+	if archetype == nil {
+		return nil, fmt.Errorf("unknown enemy %q", name)
+	}
+	enemy := level.InitEnemy(archetype)
+	if at != nil {
+		enemy.Vec = *at
+		enemy.Home = *at
+	}
+	level.Enemies = append(level.Enemies, enemy)
+	return enemy, nil
+}
+
+// randomEdgePos picks a center just inside a random edge of the view for something of the given size,
+// relative to the top left corner of the view
+func (level *Level) randomEdgePos(size Size) Vec {
+	minX, maxX := float64(size.W)/2, float64(level.View.W)-float64(size.W)/2
+	minY, maxY := float64(size.H)/2, float64(level.View.H)-float64(size.H)/2
+	switch level.Rand.Intn(4) {
+	case 0:
+		return Vec{minX + level.Rand.Float64()*(maxX-minX), minY}
+	case 1:
+		return Vec{minX + level.Rand.Float64()*(maxX-minX), maxY}
+	case 2:
+		return Vec{minX, minY + level.Rand.Float64()*(maxY-minY)}
+	default:
+		return Vec{maxX, minY + level.Rand.Float64()*(maxY-minY)}
+	}
+}
+
+// Muzzle is the point shots fired by the character start from
+func (character *Character) Muzzle() Vec {
+	return character.Vec.Add(character.Facing.Scale(character.FireOffset))
+}
+
+func (bullet *Bullet) Update(dt float64) {
+	if !bullet.FireAnimationPlayed {
+		bullet.FlashCounter++
+		if bullet.FlashCounter >= BulletFlashTicks {
+			bullet.FlashCounter = 0
+			bullet.FireAnimationPlayed = true
+		}
+	}
+	if bullet.IsColliding || bullet.Expired {
+		if !bullet.DestroyAnimationPlayed {
+			bullet.ExplodeCounter++
+			if bullet.ExplodeCounter >= BulletExplodeTicks {
+				bullet.ExplodeCounter = 0
+				bullet.DestroyAnimationPlayed = true
+			}
+		}
+		return
+	}
+	if bullet.Target != nil && bullet.Target.IsDestroyed {
+		bullet.Target = nil
 	}
+	if bullet.TurnRate > 0 && bullet.Target != nil {
+		bullet.Facing = bullet.Facing.TurnToward(bullet.Target.Vec.Sub(bullet.Vec), bullet.TurnRate*dt)
+		bullet.Vel = bullet.Facing.Scale(bullet.Speed)
+	}
+	// A bullet stops exactly at the end of its range
+	step := bullet.Vel.Scale(dt)
+	if left := bullet.Range - bullet.Travelled; step.Len() > left {
+		step = step.Normalize().Scale(left)
+	}
+	bullet.Prev = bullet.Vec
+	bullet.Vec = bullet.Vec.Add(step)
+	bullet.Travelled += step.Len()
+	bullet.Age += dt
 }
 
+func (level *Level) indexUnits() {
+	level.Units.Clear()
+	level.Units.Insert(level.Player)
+	if !level.Base.IsDestroyed {
+		level.Units.Insert(level.Base)
+	}
+	for _, enemy := range level.Enemies {
+		if !enemy.IsDestroyed {
+			level.Units.Insert(enemy)
+		}
+	}
+}
+
+// CheckBulletCollisions sweeps each bullet along the path it travelled this tick, so that
+// fast bullets hit the first thing in their way rather than skipping past thin targets.
+// A bullet that hits stops at the point of impact, and explodes there if it carries a blast.
 func (level *Level) CheckBulletCollisions() {
 	for _, bullet := range level.Bullets {
-		for _, enemy := range level.Enemies {
-			if CheckCollision(enemy, bullet) && !bullet.IsColliding && !enemy.IsDestroyed && !bullet.FiredByEnemy {
-				bullet.IsColliding = true
-				enemy.Hitpoints -= bullet.Damage
-				if enemy.Hitpoints <= 0 {
-					enemy.IsDestroyed = true
-				}
+		if bullet.IsColliding || bullet.Expired {
+			continue
+		}
+		for _, hit := range level.Units.QuerySegment(bullet.Prev, bullet.Vec, float64(bullet.W)/2) {
+			if !canHit(bullet, hit.Obj) {
+				continue
 			}
-			if CheckCollision(level.Player, bullet) && !bullet.IsColliding && bullet.FiredByEnemy {
-				bullet.IsColliding = true
-				level.Player.Hitpoints -= bullet.Damage
+			bullet.IsColliding = true
+			bullet.Vec = hit.Point
+			if bullet.Blast.Radius > 0 {
+				level.Explode(bullet.Vec, bullet.Damage, bullet.Blast, bullet.FiredByEnemy)
+			} else {
+				level.hitUnit(hit.Obj, bullet.Damage)
 			}
+			break
 		}
 	}
 }
 
-func (enemy *Enemy) Update(level *Level) {
-	if !enemy.IsDestroyed && enemy.FireRateTimer < enemy.FireRateResetValue {
-		enemy.FireRateTimer++
+// canHit reports whether bullet is allowed to strike unit
+func canHit(bullet *Bullet, unit Dimensional) bool {
+	switch unit := unit.(type) {
+	case *Enemy:
+		return !bullet.FiredByEnemy && !unit.IsDestroyed
+	case *Player:
+		return bullet.FiredByEnemy
+	case *Base:
+		return bullet.FiredByEnemy && !unit.IsDestroyed
 	}
+	return false
 }
 
-func (player *Player) Move(topBound, bottomBound, leftBound, rightBound int) {
-	newX := player.X + player.W/2 + player.Xvel
-	newY := player.Y + player.H/2 + player.Yvel
-	if player.Xvel != 0 && newX <= rightBound && newX >= leftBound {
-		player.X += player.Xvel
-		player.AtRight = false
-		player.AtLeft = false
-	} else {
-		if newX >= rightBound {
-			player.AtRight = true
+// hitUnit applies damage to unit
+func (level *Level) hitUnit(unit Dimensional, damage int) {
+	switch unit := unit.(type) {
+	case *Enemy:
+		level.damageEnemy(unit, damage)
+	case *Player:
+		level.damagePlayer(damage)
+	case *Base:
+		level.damageBase(damage)
+	}
+}
+
+// damageEnemy takes damage off enemy's hitpoints, destroying it and paying its reward once they run out
+func (level *Level) damageEnemy(enemy *Enemy, damage int) {
+	enemy.Hitpoints -= damage
+	enemy.LastHitTick = level.Tick
+	if enemy.Hitpoints <= 0 {
+		enemy.IsDestroyed = true
+		level.Ledger.Earn(level.Tick, enemy.Reward, KillBounty)
+	}
+}
+
+// SecondaryWeapon is the secondary weapon in hand, or nil if the player carries none
+func (player *Player) SecondaryWeapon() *WeaponSlot {
+	if player.Selected < 0 || player.Selected >= len(player.Secondary) {
+		return nil
+	}
+	return player.Secondary[player.Selected]
+}
+
+func (player *Player) Update(dt float64) {
+	player.Primary.Update(dt)
+	for _, slot := range player.Secondary {
+		slot.Update(dt)
+	}
+	if aim := player.Aim.Sub(player.Vec).Normalize(); aim != (Vec{}) {
+		player.Facing = aim
+	}
+}
+
+func (enemy *Enemy) Update(level *Level, dt float64) {
+	if enemy.IsDestroyed {
+		enemy.DestroyedAnimationCounter++
+		if enemy.DestroyedAnimationCounter >= EnemyExplodeTicks {
+			enemy.DestroyedAnimationPlayed = true
+		}
+		return
+	}
+	enemy.FireControl.Update(dt)
+	enemy.think(level, dt)
+	// Enemies face where their shots will meet their target while they fire at it, and otherwise the way they are going
+	facing := enemy.Vel.Normalize()
+	if enemy.IsFiring {
+		facing = LeadDirection(enemy.Vec, enemy.Target.Vec, enemy.Target.Vel, DefaultBulletSpeed)
+	}
+	if facing != (Vec{}) {
+		enemy.Facing = facing
+	}
+}
+
+// Move steps the player along the held movement keys, keeping it on the map and out of tiles
+// it cannot enter. Each axis is tried separately, so the player slides along obstacles.
+func (player *Player) Move(dt float64, tileMap *TileMap) {
+	start := player.Vec
+	step := player.Moving.Normalize().Scale(player.Speed * dt)
+	mapSize := tileMap.PixelSize()
+	w, h := float64(player.W)/2, float64(player.H)/2
+	if x := clamp(player.X+step.X, w, mapSize.X-w); player.canEnter(tileMap, Vec{x, player.Y}) {
+		player.X = x
+	}
+	if y := clamp(player.Y+step.Y, h, mapSize.Y-h); player.canEnter(tileMap, Vec{player.X, y}) {
+		player.Y = y
+	}
+	// Vel is how the player actually moved, so that enemies leading their shots are not fooled by walls
+	player.Vel = player.Vec.Sub(start).Scale(1 / dt)
+}
+
+// canEnter reports whether the player may move its center to pos. It can always move within
+// the tile it is on, so that it never gets stuck if something is built under it.
+func (player *Player) canEnter(tileMap *TileMap, pos Vec) bool {
+	p := tileMap.TileAt(pos)
+	if p == tileMap.TileAt(player.Vec) {
+		return true
+	}
+	tile := tileMap.At(p)
+	return tile != nil && tile.Open()
+}
+
+// followPlayer scrolls the camera just far enough to keep the player inside the middle of
+// the view, without ever showing past the edge of the map
+func (level *Level) followPlayer() {
+	pos := level.Player.Vec.Sub(level.Camera)
+	if pos.X < level.leftBound {
+		level.Camera.X -= level.leftBound - pos.X
+	} else if pos.X > level.rightBound {
+		level.Camera.X += pos.X - level.rightBound
+	}
+	if pos.Y < level.topBound {
+		level.Camera.Y -= level.topBound - pos.Y
+	} else if pos.Y > level.bottomBound {
+		level.Camera.Y += pos.Y - level.bottomBound
+	}
+	mapSize := level.Map.PixelSize()
+	level.Camera.X = clamp(level.Camera.X, 0, math.Max(0, mapSize.X-float64(level.View.W)))
+	level.Camera.Y = clamp(level.Camera.Y, 0, math.Max(0, mapSize.Y-float64(level.View.H)))
+}
+
+// CheckFiring fires a bullet from entity if its fire timer has run down, and returns the bullet fired, if any
+func (level *Level) CheckFiring(entity Shooter) *Bullet {
+	timer, reset, friendly := entity.GetFireSettings()
+	if timer >= reset {
+		bullet := level.InitBullet(entity.GetSelf().BulletSprite, entity.GetSelf())
+		bullet.FiredByEnemy = !friendly
+		bullet.Damage = bullet.FiredBy.Strength
+		level.Bullets = append(level.Bullets, bullet)
+		entity.SetFireTimer(0)
+		return bullet
+	}
+	return nil
+}
+
+// Update advances the level by one step of dt seconds: spawning, firing, movement,
+// collision and removal of finished bullets and enemies all happen here
+func (level *Level) Update(dt float64) {
+	if level.Outcome != Playing {
+		return
+	}
+	level.Tick++
+	level.Waves.Update(level, dt)
+
+	player := level.Player
+	player.Aim = level.Camera.Add(player.Cursor)
+	player.Update(dt)
+	player.Move(dt, level.Map)
+	level.followPlayer()
+	if !level.BuildMode {
+		level.fireWeapon(player.Primary, &player.Character)
+		if secondary := player.SecondaryWeapon(); secondary != nil {
+			level.fireWeapon(secondary, &player.Character)
 		}
-		if newX <= leftBound {
-			player.AtLeft = true
+	}
+
+	for _, enemy := range level.Enemies {
+		enemy.Update(level, dt)
+		if !enemy.IsDestroyed && enemy.IsFiring {
+			if bullet := level.CheckFiring(enemy); bullet != nil {
+				bullet.Facing = level.Jitter(bullet.Facing, enemy.Jitter)
+				bullet.Vel = bullet.Facing.Scale(bullet.Speed)
+			}
 		}
 	}
-	if player.Yvel != 0 && newY < bottomBound && newY > topBound {
-		player.Y += player.Yvel
-		player.AtBottom = false
-		player.AtTop = false
-	} else {
-		if newY >= bottomBound {
-			player.AtBottom = true
+
+	// Units do not move again this tick, so one index serves both targeting and collisions
+	level.indexUnits()
+	for _, tower := range level.Towers {
+		tower.Update(level, dt)
+	}
+
+	for _, bullet := range level.Bullets {
+		bullet.Update(dt)
+	}
+	level.updateDetonations()
+	level.CheckBulletCollisions()
+	level.expireBullets()
+
+	bulletIndex := 0
+	for _, bullet := range level.Bullets {
+		if !bullet.DestroyAnimationPlayed {
+			level.Bullets[bulletIndex] = bullet
+			bulletIndex++
 		}
-		if newY <= topBound {
-			player.AtTop = true
+	}
+	level.Bullets = level.Bullets[:bulletIndex]
+
+	enemyIndex := 0
+	for _, enemy := range level.Enemies {
+		if !enemy.DestroyedAnimationPlayed {
+			level.Enemies[enemyIndex] = enemy
+			enemyIndex++
 		}
 	}
+	level.Enemies = level.Enemies[:enemyIndex]
+	level.updateOutcome()
 }
 
-func NewGame() *Game {
+// NewGame creates a game seen through a viewWidth x viewHeight view that follows the player.
+// data describes every sprite, tower, enemy, weapon and wave the game may spawn, and seed drives all of its randomness.
+// It fails if data refers to anything it does not define, or its lanes or base do not fit on the map.
+func NewGame(viewWidth, viewHeight int, data *Data, seed int64) (*Game, error) {
+	if err := data.validate(); err != nil {
+		return nil, err
+	}
+	hash, err := data.Hash()
+	if err != nil {
+		return nil, err
+	}
 	game := &Game{}
-	game.InputChan = make(chan *Input, 2)
-	game.LevelChan = make(chan *Level, 2)
+	game.Seed = seed
+	game.DataHash = hash
+	game.InputChan = make(chan *Input, 64)
+	game.SnapshotChan = make(chan *Snapshot, 2)
+	game.TickRate = DefaultTickRate
 
 	game.Level = &Level{}
+	game.Level.Name = DefaultLevelName
+	game.Level.View = Size{viewWidth, viewHeight}
+	game.Level.Sprites = data.Sprites
+	game.Level.TowerCatalog = data.Towers
+	game.Level.EnemyCatalog = data.Enemies
+	game.Level.WeaponCatalog = data.Weapons
+	game.Level.Map = NewTestMap(300, 300)
+	game.Level.Camera = Vec{0, 0}
+	game.Level.Ledger = NewLedger(0, StartingCurrency)
+	game.Level.Rand = rand.New(rand.NewSource(seed))
+	game.Level.Units = NewSpatialHash(DefaultCellSize)
+	game.Level.topBound = float64(viewHeight) * 0.25
+	game.Level.bottomBound = float64(viewHeight) * 0.75
+	game.Level.leftBound = float64(viewWidth) * 0.25
+	game.Level.rightBound = float64(viewWidth) * 0.75
+	game.Level.Waves = NewWaveDirector(data.Waves)
+	if err := game.Level.placeLanes(data.Lanes); err != nil {
+		return nil, err
+	}
 	game.Level.initPlayer()
-	game.Level.EnemySpawnTimer = 0
 
-	return game
-}
-
-func findNextPointInTravel(dist, rotationRad float64) (int, int) {
-	nextX := dist * math.Cos(rotationRad)
-	nextY := dist * math.Sin(rotationRad)
-	return int(math.Round(nextX)), int(math.Round(nextY))
+	return game, nil
 }
 
 func (game *Game) handleInput(input *Input) {
+	player := game.Level.Player
+	if input.Type == Aim {
+		player.Cursor = input.Pos.Vec()
+		return
+	}
 	if input.Pressed {
 		switch input.Type {
-		case Up:
-			if game.Level.Player.Yvel >= -5 {
-				game.Level.Player.Yvel -= 5
+		case ToggleBuild:
+			game.Level.BuildMode = !game.Level.BuildMode
+			player.Primary.Firing = false
+		case CycleTargetPolicy:
+			if tower := game.Level.towerAt(game.Level.Camera.Add(player.Cursor)); tower != nil {
+				tower.Policy = tower.Policy.Next()
 			}
-			//game.Level.Player.Direction = DUp
-			break
-		case Down:
-			if game.Level.Player.Yvel <= 5 {
-				game.Level.Player.Yvel += 5
+		case UpgradeTower:
+			if tower := game.Level.towerAt(game.Level.Camera.Add(player.Cursor)); tower != nil {
+				game.Level.UpgradeTower(tower, input.Option)
 			}
-			//game.Level.Player.Direction = DDown
-			break
-		case Left:
-			if game.Level.Player.Xvel >= -5 {
-				game.Level.Player.Xvel -= 5
+		case SellTower:
+			if tower := game.Level.towerAt(game.Level.Camera.Add(player.Cursor)); tower != nil {
+				game.Level.SellTower(tower)
 			}
-			//game.Level.Player.Direction = DLeft
-			break
+		case Up:
+			player.Moving.Y = -1
+		case Down:
+			player.Moving.Y = 1
+		case Left:
+			player.Moving.X = -1
 		case Right:
-			if game.Level.Player.Xvel <= 5 {
-				game.Level.Player.Xvel += 5
-			}
-			//game.Level.Player.Direction = DRight
-			break
+			player.Moving.X = 1
 		case FirePrimary:
-			game.Level.Player.IsFiring = true
-			game.Level.Player.FireRateTimer = game.Level.Player.FireRateResetValue
+			if game.Level.BuildMode {
+				game.Level.BuildTower(game.Level.Camera.Add(player.Cursor))
+				return
+			}
+			player.Primary.Firing = true
+		case FireSecondary:
+			if secondary := player.SecondaryWeapon(); secondary != nil {
+				secondary.Firing = true
+			}
+		case SelectSecondary:
+			if input.Option >= 0 && input.Option < len(player.Secondary) {
+				if secondary := player.SecondaryWeapon(); secondary != nil {
+					secondary.Firing = false
+				}
+				player.Selected = input.Option
+			}
 		default:
 			//fmt.Println("Some input pressed")
 		}
 	} else {
 		switch input.Type {
 		case Up:
-			if game.Level.Player.Yvel < 0 {
-				game.Level.Player.Yvel = 0
+			if player.Moving.Y < 0 {
+				player.Moving.Y = 0
 			}
-			break
 		case Down:
-			if game.Level.Player.Yvel > 0 {
-				game.Level.Player.Yvel = 0
+			if player.Moving.Y > 0 {
+				player.Moving.Y = 0
 			}
-			break
 		case Left:
-			if game.Level.Player.Xvel < 0 {
-				game.Level.Player.Xvel = 0
+			if player.Moving.X < 0 {
+				player.Moving.X = 0
 			}
-			break
 		case Right:
-			if game.Level.Player.Xvel > 0 {
-				game.Level.Player.Xvel = 0
+			if player.Moving.X > 0 {
+				player.Moving.X = 0
 			}
-			break
 		case FirePrimary:
-			game.Level.Player.IsFiring = false
+			player.Primary.Firing = false
+		case FireSecondary:
+			if secondary := player.SecondaryWeapon(); secondary != nil {
+				secondary.Firing = false
+			}
 		default:
 			//fmt.Println("Some input not pressed")
 		}
 	}
 }
 
-func FindDegreeRotation(originY, originX, pointY, pointX int32) float64 {
-	return math.Atan2(float64(pointY-originY), float64(pointX-originX)) * (180.0 / math.Pi)
-}
-
 func DegreeToRad(degree float64) float64 {
 	return degree * (math.Pi / 180)
 }
 
+// Run advances the simulation at a fixed TickRate. Each tick applies every input received
+// since the previous tick, steps all entities and publishes a snapshot, whether or not any input arrived.
+// The Level itself is only ever touched from the goroutine running Run. Run returns after a Quit input.
 func (game *Game) Run() {
-	game.LevelChan <- game.Level
+	ticker := time.NewTicker(time.Second / time.Duration(game.TickRate))
+	defer ticker.Stop()
 
-	for input := range game.InputChan {
-		if input.Type == None {
-			continue
+	game.SnapshotChan <- game.snapshot()
+	game.published = game.Level.Ledger.Emitted()
+
+	for range ticker.C {
+		if !game.drainInput() {
+			close(game.SnapshotChan)
+			return
 		}
-		if input.Type == Quit {
-			close(game.LevelChan)
-			close(game.InputChan)
-			os.Exit(0)
+		game.playInput()
+		game.tick()
+		game.publish()
+	}
+}
+
+// drainInput applies every input waiting on InputChan and reports false once a Quit arrives
+func (game *Game) drainInput() bool {
+	for {
+		select {
+		case input := <-game.InputChan:
+			if input.Type == None {
+				continue
+			}
+			if input.Type == Quit {
+				return false
+			}
+			if game.playback != nil {
+				continue
+			}
+			if game.Recording != nil {
+				game.Recording.record(game.Level.Tick, input)
+			}
+			game.handleInput(input)
+		default:
+			return true
 		}
-		game.handleInput(input)
+	}
+}
+
+func (game *Game) tick() {
+	game.Level.Update(1.0 / float64(game.TickRate))
+}
+
+func (game *Game) snapshot() *Snapshot {
+	snapshot := game.Level.Snapshot()
+	snapshot.HUD.Seed = game.Seed
+	snapshot.HUD.Transactions = game.Level.Ledger.Since(game.published)
+	snapshot.HUD.TickRate = game.TickRate
+	return snapshot
+}
 
-		game.LevelChan <- game.Level
+// publish hands a snapshot to the renderer without blocking; if the renderer has fallen
+// behind, it already has a pending snapshot to draw and this tick is simply not shown
+func (game *Game) publish() {
+	snapshot := game.snapshot()
+	select {
+	case game.SnapshotChan <- snapshot:
+		game.published = game.Level.Ledger.Emitted()
+	default:
 	}
 }
@@ -0,0 +1,81 @@
+package game
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func loadTestData(t *testing.T) *Data {
+	t.Helper()
+	data, err := LoadData(filepath.Join("..", "gui", "assets", "images"), "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func newTestGame(t *testing.T, seed int64) *Game {
+	t.Helper()
+	game, err := NewGame(1920, 1080, loadTestData(t), seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return game
+}
+
+func TestRunPublishesSnapshotsToAnotherGoroutine(t *testing.T) {
+	game := newTestGame(t, 1)
+	game.TickRate = 1000
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	received := 0
+	lastTick := -1
+	go func() {
+		defer wg.Done()
+		for snapshot := range game.SnapshotChan {
+			if snapshot.Tick < lastTick {
+				t.Errorf("snapshot for tick %d arrived after tick %d", snapshot.Tick, lastTick)
+			}
+			lastTick = snapshot.Tick
+			received++
+			// Read everything, so the race detector sees any memory shared with the Level
+			for _, sprites := range [][]Sprite{snapshot.Ground, snapshot.Obstacles, snapshot.Enemies, snapshot.Towers, snapshot.Bullets} {
+				for _, sprite := range sprites {
+					_ = sprite.Vec.Add(Vec{float64(sprite.W), float64(sprite.H)})
+				}
+			}
+			for _, effect := range snapshot.Effects {
+				_ = effect.Frame
+			}
+			for _, event := range snapshot.HUD.Transactions {
+				_ = event.Balance
+			}
+			_ = snapshot.HUD.Weapons
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		game.Run()
+		close(done)
+	}()
+	for tick := 0; tick <= 1200; tick += 30 {
+		for _, input := range session[tick] {
+			game.InputChan <- input
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	game.InputChan <- &Input{Type: Quit}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return after Quit")
+	}
+	wg.Wait()
+	if received < 2 {
+		t.Errorf("received %d snapshots, want at least 2", received)
+	}
+}
@@ -0,0 +1,246 @@
+package game
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultLives is how many enemies may leak into the goal before the player loses, unless the lanes file says otherwise
+const DefaultLives = 20
+
+// Directions a road tile connects to, combined into a mask
+const (
+	roadNorth = 1 << iota
+	roadEast
+	roadSouth
+	roadWest
+)
+
+// roadSprites names the road tile, after its ground's prefix, for each mask of connections.
+// A lane's two ends connect one way only, and are drawn as straight road.
+var roadSprites = map[int]string{
+	roadNorth:                                   "_roadNorth",
+	roadSouth:                                   "_roadNorth",
+	roadNorth | roadSouth:                       "_roadNorth",
+	roadEast:                                    "_roadEast",
+	roadWest:                                    "_roadEast",
+	roadEast | roadWest:                         "_roadEast",
+	roadNorth | roadEast:                        "_roadCornerUR",
+	roadNorth | roadWest:                        "_roadCornerUL",
+	roadSouth | roadEast:                        "_roadCornerLR",
+	roadSouth | roadWest:                        "_roadCornerLL",
+	roadNorth | roadSouth | roadEast:            "_roadSplitE",
+	roadNorth | roadSouth | roadWest:            "_roadSplitW",
+	roadEast | roadWest | roadNorth:             "_roadSplitN",
+	roadEast | roadWest | roadSouth:             "_roadSplitS",
+	roadNorth | roadEast | roadSouth | roadWest: "_roadCrossing",
+}
+
+// A Lane is a road that enemies drive along from where they spawn to the goal at its far end
+type Lane struct {
+	Name string `json:"-"`
+	// Waypoints are the tile coordinates of the lane's start, each turn and its end, joined by straight runs of road
+	Waypoints []Pos `json:"waypoints"`
+	// Tiles is every tile along the lane in order, from start to goal
+	Tiles []Pos `json:"-"`
+}
+
+// LaneSet is every lane on the map, how many enemies may leak through them, and the base they lead to
+type LaneSet struct {
+	Lives int              `json:"lives"`
+	Lanes map[string]*Lane `json:"lanes"`
+	Base  *BaseSpec        `json:"base"`
+}
+
+func LoadLanes(filename string) (*LaneSet, error) {
+	lanes := &LaneSet{}
+	if err := loadJSON(filename, lanes); err != nil {
+		return nil, err
+	}
+	if lanes.Lives == 0 {
+		lanes.Lives = DefaultLives
+	}
+	if lanes.Base == nil {
+		return nil, fmt.Errorf("lanes %s: no base to defend", filename)
+	}
+	if err := lanes.Base.validate(); err != nil {
+		return nil, fmt.Errorf("lanes %s: %v", filename, err)
+	}
+	for name, lane := range lanes.Lanes {
+		lane.Name = name
+		if len(lane.Waypoints) < 2 {
+			return nil, fmt.Errorf("lanes %s: %s needs a start and an end", filename, name)
+		}
+		lane.Tiles = []Pos{lane.Waypoints[0]}
+		for i := 1; i < len(lane.Waypoints); i++ {
+			from, to := lane.Waypoints[i-1], lane.Waypoints[i]
+			if from.X != to.X && from.Y != to.Y {
+				return nil, fmt.Errorf("lanes %s: %s turns between %v and %v other than at a waypoint", filename, name, from, to)
+			}
+			for p := from; p != to; {
+				p = Pos{p.X + sign(to.X-p.X), p.Y + sign(to.Y-p.Y)}
+				lane.Tiles = append(lane.Tiles, p)
+			}
+		}
+		if len(lane.Tiles) < 2 {
+			return nil, fmt.Errorf("lanes %s: %s starts and ends on the same tile", filename, name)
+		}
+		for _, p := range lane.Tiles {
+			if p.X < 0 || p.Y < 0 {
+				return nil, fmt.Errorf("lanes %s: %s runs off the map at %v", filename, name, p)
+			}
+		}
+	}
+	return lanes, nil
+}
+
+// placeLanes lays lanes and the base they lead to onto the level's map. Every lane has to lie
+// on the map and end at the base, which blocks its tile to units and towers alike.
+func (level *Level) placeLanes(lanes *LaneSet) error {
+	spec := lanes.Base
+	if level.Map.At(spec.Tile) == nil {
+		return fmt.Errorf("base is off the map at %v", spec.Tile)
+	}
+	for name, lane := range lanes.Lanes {
+		for _, p := range lane.Tiles {
+			if level.Map.At(p) == nil {
+				return fmt.Errorf("lane %s runs off the map at %v", name, p)
+			}
+		}
+		if end := lane.Tiles[len(lane.Tiles)-1]; end != spec.Tile {
+			return fmt.Errorf("lane %s ends at %v rather than at the base at %v", name, end, spec.Tile)
+		}
+	}
+	level.Lanes = lanes.Lanes
+	level.Lives = lanes.Lives
+	for _, lane := range level.Lanes {
+		level.Map.LayLane(lane)
+	}
+	level.Base = level.InitBase(spec)
+	level.Map.At(spec.Tile).Impassable = true
+	return nil
+}
+
+// Start is the world point enemies on the lane spawn at
+func (lane *Lane) Start(tileMap *TileMap) Vec {
+	return tileMap.Center(lane.Tiles[0])
+}
+
+// LayLane paves lane onto the map as road, clearing any obstacles in its way. Where lanes meet,
+// the road joins them up.
+func (tileMap *TileMap) LayLane(lane *Lane) {
+	for i, p := range lane.Tiles {
+		tile := tileMap.At(p)
+		if tile == nil {
+			continue
+		}
+		if i > 0 {
+			tile.roads |= roadToward(p, lane.Tiles[i-1])
+		}
+		if i < len(lane.Tiles)-1 {
+			tile.roads |= roadToward(p, lane.Tiles[i+1])
+		}
+		tile.IsTrack = true
+		tile.Obstacle = ""
+		tile.Sprite = SpriteID(groundName(tile.Sprite) + roadSprites[tile.roads])
+	}
+}
+
+// roadToward is the direction from tile p to its neighbor q
+func roadToward(p, q Pos) int {
+	switch {
+	case q.Y < p.Y:
+		return roadNorth
+	case q.X > p.X:
+		return roadEast
+	case q.Y > p.Y:
+		return roadSouth
+	default:
+		return roadWest
+	}
+}
+
+// groundName is the kind of ground a tile sprite shows, such as tileGrass or tileSand
+func groundName(sprite SpriteID) string {
+	name := string(sprite)
+	for i, r := range name {
+		if r == '_' || (r >= '0' && r <= '9') {
+			return name[:i]
+		}
+	}
+	return name
+}
+
+// JoinLane sets enemy driving along lane from its start
+func (enemy *Enemy) JoinLane(lane *Lane) {
+	enemy.Lane = lane
+	enemy.laneIndex = 0
+}
+
+// followLane drives the enemy on along its lane, and leaks it into the goal once it reaches the end
+func (enemy *Enemy) followLane(level *Level, dt float64) {
+	start := enemy.Vec
+	remaining := enemy.Speed * dt
+	for remaining > 0 && enemy.laneIndex < len(enemy.Lane.Tiles) {
+		target := level.Map.Center(enemy.Lane.Tiles[enemy.laneIndex])
+		toTarget := target.Sub(enemy.Vec)
+		dist := toTarget.Len()
+		if dist > remaining {
+			enemy.Vec = enemy.Vec.Add(toTarget.Scale(remaining / dist))
+			break
+		}
+		enemy.Vec = target
+		remaining -= dist
+		enemy.laneIndex++
+	}
+	enemy.Vel = enemy.Vec.Sub(start).Scale(1 / dt)
+	if enemy.laneIndex == len(enemy.Lane.Tiles) {
+		level.leak(enemy)
+	}
+}
+
+// Remaining is how far, in pixels, the enemy still has to drive along its lane, or along its
+// planned path if it is not on one. An enemy with neither has nowhere to get to, and is
+// infinitely far from it.
+func (enemy *Enemy) Remaining(tileMap *TileMap) float64 {
+	var tiles []Pos
+	switch {
+	case enemy.Lane != nil:
+		tiles = enemy.Lane.Tiles[enemy.laneIndex:]
+	case len(enemy.Path) > 0:
+		tiles = enemy.Path
+	default:
+		return math.Inf(1)
+	}
+	remaining := 0.0
+	at := enemy.Vec
+	for _, p := range tiles {
+		next := tileMap.Center(p)
+		remaining += at.Dist(next)
+		at = next
+	}
+	return remaining
+}
+
+// leak takes an enemy that reached the goal out of the level, without a bounty, and costs the player
+// its lives. It crashes into the base on the way out, doing its strength in damage.
+func (level *Level) leak(enemy *Enemy) {
+	enemy.IsDestroyed = true
+	enemy.DestroyedAnimationPlayed = true
+	enemy.Leaked = true
+	level.damageBase(enemy.Strength)
+	level.Lives -= enemy.LivesCost
+	if level.Lives < 0 {
+		level.Lives = 0
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadLanes(t *testing.T) {
+	const base = `"base": {"sprite": "hq", "hitpoints": 100, "tile": {"x": 3, "y": 2}}`
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+		// tiles is what the lane named "a" should run through, when loading succeeds
+		tiles []Pos
+		lives int
+	}{
+		{"straight", `{` + base + `, "lanes": {"a": {"waypoints": [{"x": 0, "y": 2}, {"x": 3, "y": 2}]}}}`,
+			false, []Pos{{0, 2}, {1, 2}, {2, 2}, {3, 2}}, DefaultLives},
+		{"turning", `{"lives": 5, ` + base + `, "lanes": {"a": {"waypoints": [{"x": 1, "y": 0}, {"x": 1, "y": 2}, {"x": 3, "y": 2}]}}}`,
+			false, []Pos{{1, 0}, {1, 1}, {1, 2}, {2, 2}, {3, 2}}, 5},
+		{"backwards", `{` + base + `, "lanes": {"a": {"waypoints": [{"x": 5, "y": 2}, {"x": 3, "y": 2}]}}}`,
+			false, []Pos{{5, 2}, {4, 2}, {3, 2}}, DefaultLives},
+		{"diagonal", `{` + base + `, "lanes": {"a": {"waypoints": [{"x": 0, "y": 0}, {"x": 3, "y": 2}]}}}`, true, nil, 0},
+		{"one tile", `{` + base + `, "lanes": {"a": {"waypoints": [{"x": 3, "y": 2}, {"x": 3, "y": 2}]}}}`, true, nil, 0},
+		{"one waypoint", `{` + base + `, "lanes": {"a": {"waypoints": [{"x": 3, "y": 2}]}}}`, true, nil, 0},
+		{"off the map", `{` + base + `, "lanes": {"a": {"waypoints": [{"x": -1, "y": 2}, {"x": 3, "y": 2}]}}}`, true, nil, 0},
+		{"no base", `{"lanes": {"a": {"waypoints": [{"x": 0, "y": 2}, {"x": 3, "y": 2}]}}}`, true, nil, 0},
+		{"base without hitpoints", `{"base": {"sprite": "hq", "tile": {"x": 3, "y": 2}}, "lanes": {}}`, true, nil, 0},
+		{"not json", `{"lanes": `, true, nil, 0},
+	}
+	dir := t.TempDir()
+	for i, test := range tests {
+		filename := filepath.Join(dir, "lanes"+string(rune('a'+i))+".json")
+		if err := os.WriteFile(filename, []byte(test.json), 0644); err != nil {
+			t.Fatal(err)
+		}
+		lanes, err := LoadLanes(filename)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: LoadLanes error = %v, want error %v", test.name, err, test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		lane := lanes.Lanes["a"]
+		if lane.Name != "a" || !reflect.DeepEqual(lane.Tiles, test.tiles) {
+			t.Errorf("%s: lane %q runs through %v, want %v", test.name, lane.Name, lane.Tiles, test.tiles)
+		}
+		if lanes.Lives != test.lives {
+			t.Errorf("%s: %d lives, want %d", test.name, lanes.Lives, test.lives)
+		}
+	}
+}
+
+func TestWaveGroupSpawnsOnLane(t *testing.T) {
+	game := newTestGame(t, 1)
+	level := game.Level
+	lane := level.Lanes["west"]
+	director := NewWaveDirector([]*Wave{{Groups: []*WaveGroup{{Enemy: "scout", Count: 2, Lane: "west"}}}})
+	director.Update(level, 0.25)
+	if len(level.Enemies) != 2 {
+		t.Fatalf("%d enemies spawned, want 2", len(level.Enemies))
+	}
+	for _, enemy := range level.Enemies {
+		if enemy.Lane != lane || enemy.Vec != lane.Start(level.Map) {
+			t.Errorf("enemy spawned at %v off the start of its lane", enemy.Vec)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package game
+
+import "fmt"
+
+// StartingCurrency is the balance every game begins with
+const StartingCurrency = 200
+
+// LedgerHistory is how many of the latest events a ledger keeps
+const LedgerHistory = 256
+
+type LedgerReason int
+
+const (
+	StartingFunds LedgerReason = iota
+	KillBounty
+	WaveIncome
+	TowerBuild
+	TowerUpgrade
+	TowerSale
+)
+
+var ledgerReasonNames = [...]string{"starting funds", "kill", "wave income", "build", "upgrade", "sale"}
+
+func (reason LedgerReason) String() string {
+	if reason < 0 || int(reason) >= len(ledgerReasonNames) {
+		return "unknown"
+	}
+	return ledgerReasonNames[reason]
+}
+
+// A LedgerEvent records one attempt to change the balance. Amount is positive for earnings
+// and negative for spending. A rejected spend leaves Balance as it was.
+type LedgerEvent struct {
+	Tick     int
+	Reason   LedgerReason
+	Amount   int
+	Balance  int
+	Rejected bool
+}
+
+// Ledger holds the player's currency. Every earning and spending goes through it, so the
+// balance can never go negative and each change is recorded in History, which keeps the
+// latest LedgerHistory of them.
+type Ledger struct {
+	balance int
+	History []LedgerEvent
+	// emitted counts every event there has ever been, including those History no longer keeps
+	emitted int
+}
+
+func NewLedger(tick, startingBalance int) *Ledger {
+	ledger := &Ledger{}
+	ledger.Earn(tick, startingBalance, StartingFunds)
+	return ledger
+}
+
+func (ledger *Ledger) Balance() int {
+	return ledger.balance
+}
+
+func (ledger *Ledger) CanAfford(amount int) bool {
+	return amount <= ledger.balance
+}
+
+// Emitted is the number of events the ledger has recorded so far
+func (ledger *Ledger) Emitted() int {
+	return ledger.emitted
+}
+
+// Since returns a copy of the events recorded after the first n, oldest first, as far back as History goes
+func (ledger *Ledger) Since(n int) []LedgerEvent {
+	start := len(ledger.History) - (ledger.emitted - n)
+	if start < 0 {
+		start = 0
+	}
+	return append([]LedgerEvent(nil), ledger.History[start:]...)
+}
+
+func (ledger *Ledger) Earn(tick, amount int, reason LedgerReason) {
+	if amount <= 0 {
+		return
+	}
+	ledger.balance += amount
+	ledger.emit(LedgerEvent{tick, reason, amount, ledger.balance, false})
+}
+
+// Spend takes amount from the balance, or returns an error and changes nothing if the balance is too low.
+// A negative amount is an error too, rather than a way to earn.
+func (ledger *Ledger) Spend(tick, amount int, reason LedgerReason) error {
+	if amount < 0 {
+		return fmt.Errorf("%s cannot cost a negative %d", reason, amount)
+	}
+	if !ledger.CanAfford(amount) {
+		ledger.emit(LedgerEvent{tick, reason, -amount, ledger.balance, true})
+		return fmt.Errorf("%s costs %d but the balance is %d", reason, amount, ledger.balance)
+	}
+	ledger.balance -= amount
+	ledger.emit(LedgerEvent{tick, reason, -amount, ledger.balance, false})
+	return nil
+}
+
+func (ledger *Ledger) emit(event LedgerEvent) {
+	ledger.History = append(ledger.History, event)
+	ledger.emitted++
+	if len(ledger.History) > LedgerHistory {
+		ledger.History = ledger.History[len(ledger.History)-LedgerHistory:]
+	}
+}
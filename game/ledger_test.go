@@ -0,0 +1,62 @@
+package game
+
+import "testing"
+
+func TestLedgerSpend(t *testing.T) {
+	tests := []struct {
+		name        string
+		balance     int
+		amount      int
+		wantErr     bool
+		wantBalance int
+	}{
+		{"affordable", 200, 150, false, 50},
+		{"exactly the balance", 200, 200, false, 0},
+		{"too expensive", 200, 201, true, 200},
+		{"free", 200, 0, false, 200},
+		{"empty ledger", 0, 1, true, 0},
+		{"negative", 10, -100, true, 10},
+	}
+	for _, test := range tests {
+		ledger := NewLedger(0, test.balance)
+		emitted := ledger.Emitted()
+		err := ledger.Spend(5, test.amount, TowerBuild)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: Spend error = %v, want error %v", test.name, err, test.wantErr)
+		}
+		if ledger.Balance() != test.wantBalance {
+			t.Errorf("%s: balance is %d, want %d", test.name, ledger.Balance(), test.wantBalance)
+		}
+		if test.amount < 0 {
+			if ledger.Emitted() != emitted {
+				t.Errorf("%s: recorded an event for a negative spend", test.name)
+			}
+			continue
+		}
+		last := ledger.History[len(ledger.History)-1]
+		want := LedgerEvent{5, TowerBuild, -test.amount, test.wantBalance, test.wantErr}
+		if last != want {
+			t.Errorf("%s: recorded %+v, want %+v", test.name, last, want)
+		}
+	}
+}
+
+func TestLedgerHistoryIsCapped(t *testing.T) {
+	ledger := NewLedger(0, StartingCurrency)
+	for tick := 1; tick <= LedgerHistory*2; tick++ {
+		ledger.Earn(tick, 1, KillBounty)
+	}
+	if len(ledger.History) != LedgerHistory {
+		t.Errorf("history holds %d events, want %d", len(ledger.History), LedgerHistory)
+	}
+	if ledger.Emitted() != LedgerHistory*2+1 {
+		t.Errorf("emitted %d events, want %d", ledger.Emitted(), LedgerHistory*2+1)
+	}
+	since := ledger.Since(ledger.Emitted() - 3)
+	if len(since) != 3 || since[2].Tick != LedgerHistory*2 {
+		t.Errorf("Since returned %+v, want the last 3 events", since)
+	}
+	if len(ledger.Since(0)) != LedgerHistory {
+		t.Errorf("Since(0) returned %d events, want all %d kept", len(ledger.Since(0)), LedgerHistory)
+	}
+}
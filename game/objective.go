@@ -0,0 +1,95 @@
+package game
+
+import "fmt"
+
+// Outcome is whether the level is still being played, and if not, how it ended
+type Outcome int
+
+const (
+	Playing Outcome = iota
+	// Victory comes once every wave has spawned and every enemy is gone
+	Victory
+	// Defeat comes when the base or the player is destroyed, or the player runs out of lives
+	Defeat
+)
+
+var outcomeNames = [...]string{"playing", "victory", "defeat"}
+
+func (outcome Outcome) String() string {
+	if outcome < 0 || int(outcome) >= len(outcomeNames) {
+		return "unknown"
+	}
+	return outcomeNames[outcome]
+}
+
+// BaseSpec describes the base the player defends
+type BaseSpec struct {
+	Sprite    SpriteID `json:"sprite"`
+	Hitpoints int      `json:"hitpoints"`
+	// Tile is where on the map the base stands, in tile coordinates
+	Tile Pos `json:"tile"`
+}
+
+func (spec *BaseSpec) validate() error {
+	if spec.Hitpoints <= 0 {
+		return fmt.Errorf("base needs positive hitpoints")
+	}
+	if spec.Tile.X < 0 || spec.Tile.Y < 0 {
+		return fmt.Errorf("base is off the map at %v", spec.Tile)
+	}
+	return nil
+}
+
+// Base is the headquarters the player must protect. Enemies shoot at it, and those that leak
+// into the goal crash into it; the level is lost if it is destroyed.
+type Base struct {
+	Character
+	// MaxHitpoints is what the base started with
+	MaxHitpoints int
+	Tile         Pos
+}
+
+func (level *Level) InitBase(spec *BaseSpec) *Base {
+	base := &Base{}
+	base.Sprite = spec.Sprite
+	base.Size = level.Sprites[base.Sprite]
+	base.Collider = Box
+	base.Hitpoints = spec.Hitpoints
+	base.MaxHitpoints = spec.Hitpoints
+	base.IsDestroyed = false
+	base.Tile = spec.Tile
+	base.Vec = level.Map.Center(spec.Tile)
+	base.Facing = Vec{0, 1}
+	return base
+}
+
+// damageBase takes damage off the base's hitpoints, destroying it once they run out
+func (level *Level) damageBase(damage int) {
+	base := level.Base
+	base.Hitpoints -= damage
+	if base.Hitpoints <= 0 {
+		base.Hitpoints = 0
+		base.IsDestroyed = true
+	}
+}
+
+// damagePlayer takes damage off the player's hitpoints, destroying it once they run out
+func (level *Level) damagePlayer(damage int) {
+	player := level.Player
+	player.Hitpoints -= damage
+	if player.Hitpoints <= 0 {
+		player.Hitpoints = 0
+		player.IsDestroyed = true
+	}
+}
+
+// updateOutcome ends the level once it has been won or lost
+func (level *Level) updateOutcome() {
+	switch {
+	case level.Outcome != Playing:
+	case level.Base.IsDestroyed || level.Player.IsDestroyed || level.Lives == 0:
+		level.Outcome = Defeat
+	case level.Waves.Done() && len(level.Enemies) == 0:
+		level.Outcome = Victory
+	}
+}
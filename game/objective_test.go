@@ -0,0 +1,145 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUpdateOutcome(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(level *Level)
+		want  Outcome
+	}{
+		{"waves still to come", func(level *Level) {}, Playing},
+		{"enemies left", func(level *Level) {
+			level.Waves = NewWaveDirector(nil)
+			if _, err := level.SpawnEnemy("tank", nil); err != nil {
+				t.Fatal(err)
+			}
+		}, Playing},
+		{"all waves done and no enemies left", func(level *Level) { level.Waves = NewWaveDirector(nil) }, Victory},
+		{"base destroyed", func(level *Level) { level.damageBase(level.Base.Hitpoints) }, Defeat},
+		{"player destroyed", func(level *Level) { level.damagePlayer(level.Player.Hitpoints + 50) }, Defeat},
+		{"lives run out", func(level *Level) {
+			level.Lives = 1
+			enemy, err := level.SpawnEnemy("scout", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			level.leak(enemy)
+		}, Defeat},
+		{"defeat beats victory", func(level *Level) {
+			level.Waves = NewWaveDirector(nil)
+			level.damageBase(level.Base.Hitpoints)
+		}, Defeat},
+	}
+	for _, test := range tests {
+		level := newTestGame(t, 1).Level
+		test.setup(level)
+		level.updateOutcome()
+		if level.Outcome != test.want {
+			t.Errorf("%s: outcome is %v, want %v", test.name, level.Outcome, test.want)
+		}
+	}
+}
+
+func TestOutcomeIsFinal(t *testing.T) {
+	level := newTestGame(t, 1).Level
+	level.Waves = NewWaveDirector(nil)
+	level.Update(1.0 / 60)
+	if level.Outcome != Victory {
+		t.Fatalf("outcome is %v, want %v", level.Outcome, Victory)
+	}
+	tick := level.Tick
+	level.damageBase(level.Base.Hitpoints)
+	level.Update(1.0 / 60)
+	if level.Outcome != Victory || level.Tick != tick {
+		t.Errorf("level went on to %v at tick %d after being won at tick %d", level.Outcome, level.Tick, tick)
+	}
+	if level.Player.Hitpoints < 0 || level.Base.Hitpoints < 0 {
+		t.Errorf("hitpoints went negative: player %d, base %d", level.Player.Hitpoints, level.Base.Hitpoints)
+	}
+}
+
+// soakInputs plays the scripted session, then drives the player round in circles firing
+func soakInputs(ticks int) map[int][]*Input {
+	inputs := map[int][]*Input{}
+	for tick, batch := range session {
+		inputs[tick] = batch
+	}
+	for tick := 1500; tick < ticks; tick += 500 {
+		dir := []InputType{Up, Right, Down, Left}[(tick/500)%4]
+		inputs[tick] = []*Input{{Type: dir, Pressed: true}, {Type: FirePrimary, Pressed: true}}
+		inputs[tick+250] = []*Input{{Type: dir, Pressed: false}, {Type: FirePrimary, Pressed: false}}
+	}
+	return inputs
+}
+
+// soak runs game for ticks, failing the test as soon as the level gets into a state it never
+// should, and returns the tick the level was decided on, or 0 if it still is being played
+func soak(t *testing.T, game *Game, ticks int) int {
+	t.Helper()
+	level := game.Level
+	inputs := soakInputs(ticks)
+	outcomeTick := 0
+	for i := 0; i < ticks; i++ {
+		step(game, 1, map[int][]*Input{0: inputs[i]})
+		if level.Outcome != Playing && outcomeTick == 0 {
+			outcomeTick = level.Tick
+		}
+		if outcomeTick != 0 && level.Tick != outcomeTick {
+			t.Fatalf("level was decided at tick %d but went on to tick %d", outcomeTick, level.Tick)
+		}
+		if level.Ledger.Balance() < 0 {
+			t.Fatalf("tick %d: balance went negative: %d", level.Tick, level.Ledger.Balance())
+		}
+		if level.Player.Hitpoints < 0 || level.Base.Hitpoints < 0 || level.Lives < 0 {
+			t.Fatalf("tick %d: player has %d hitpoints, base %d, lives %d", level.Tick, level.Player.Hitpoints, level.Base.Hitpoints, level.Lives)
+		}
+		if !finite(level.Player.Vec) {
+			t.Fatalf("tick %d: player is at %v", level.Tick, level.Player.Vec)
+		}
+		for _, enemy := range level.Enemies {
+			if !finite(enemy.Vec) || !finite(enemy.Vel) {
+				t.Fatalf("tick %d: %s enemy is at %v moving %v", level.Tick, enemy.Archetype, enemy.Vec, enemy.Vel)
+			}
+		}
+		for _, bullet := range level.Bullets {
+			if !finite(bullet.Vec) {
+				t.Fatalf("tick %d: bullet is at %v", level.Tick, bullet.Vec)
+			}
+		}
+		if len(level.Bullets) > 2000 || len(level.Enemies) > 500 {
+			t.Fatalf("tick %d: %d bullets and %d enemies are piling up", level.Tick, len(level.Bullets), len(level.Enemies))
+		}
+	}
+	return outcomeTick
+}
+
+func TestLevelSoak(t *testing.T) {
+	// Sturdy enough to see every wave through
+	game := newTestGame(t, 7)
+	level := game.Level
+	level.Player.Hitpoints = math.MaxInt32
+	level.Base.Hitpoints = math.MaxInt32
+	level.Lives = math.MaxInt32
+	if tick := soak(t, game, 20000); tick != 0 && level.Outcome != Victory {
+		t.Errorf("sturdy level ended in %v at tick %d", level.Outcome, tick)
+	}
+	if !level.Waves.Done() {
+		t.Errorf("only %d of %d waves started", level.Waves.Current, len(level.Waves.Waves))
+	}
+}
+
+func TestLevelSoakToOutcome(t *testing.T) {
+	// With the shipped hitpoints and lives, the level is decided one way or the other well within the soak
+	game := newTestGame(t, 7)
+	if tick := soak(t, game, 20000); tick == 0 {
+		t.Errorf("level is still being played after %d ticks", game.Level.Tick)
+	}
+}
+
+func finite(v Vec) bool {
+	return !math.IsNaN(v.X) && !math.IsNaN(v.Y) && !math.IsInf(v.X, 0) && !math.IsInf(v.Y, 0)
+}
@@ -0,0 +1,127 @@
+package game
+
+import (
+	"container/heap"
+	"math"
+)
+
+// MaxPathNodes caps how many tiles one search may expand, so that an unreachable goal on a
+// large map costs a bounded amount of time
+const MaxPathNodes = 4096
+
+// ReplanTicks is how often an enemy following a path plans it afresh, even if nothing seems to have changed
+const ReplanTicks = 30
+
+// neighbors are the eight tile steps a path may take, each with its cost in tiles
+var neighbors = [8]struct {
+	step Pos
+	cost float64
+}{
+	{Pos{1, 0}, 1}, {Pos{-1, 0}, 1}, {Pos{0, 1}, 1}, {Pos{0, -1}, 1},
+	{Pos{1, 1}, math.Sqrt2}, {Pos{1, -1}, math.Sqrt2}, {Pos{-1, 1}, math.Sqrt2}, {Pos{-1, -1}, math.Sqrt2},
+}
+
+// FindPath plans the cheapest route over tiles from from to to with A*, moving in eight
+// directions but never cutting the corner of a tile that cannot be entered. passable says
+// which tiles can be entered; from itself never has to be. The path lists the tiles to visit
+// after from, ending with to, and is nil if to cannot be reached.
+func (tileMap *TileMap) FindPath(from, to Pos, passable func(Pos) bool) []Pos {
+	if from == to {
+		return []Pos{}
+	}
+	if !passable(to) {
+		return nil
+	}
+	open := &pathQueue{}
+	cameFrom := map[Pos]Pos{}
+	cost := map[Pos]float64{from: 0}
+	closed := map[Pos]bool{}
+	heap.Push(open, &pathNode{from, octile(from, to), 0})
+	for expanded := 0; open.Len() > 0 && expanded < MaxPathNodes; expanded++ {
+		current := heap.Pop(open).(*pathNode).Pos
+		if current == to {
+			return reconstructPath(cameFrom, from, to)
+		}
+		if closed[current] {
+			continue
+		}
+		closed[current] = true
+		for _, n := range neighbors {
+			next := Pos{current.X + n.step.X, current.Y + n.step.Y}
+			if closed[next] || !passable(next) {
+				continue
+			}
+			if n.step.X != 0 && n.step.Y != 0 &&
+				(!passable(Pos{current.X + n.step.X, current.Y}) || !passable(Pos{current.X, current.Y + n.step.Y})) {
+				continue
+			}
+			nextCost := cost[current] + n.cost
+			if known, ok := cost[next]; ok && known <= nextCost {
+				continue
+			}
+			cost[next] = nextCost
+			cameFrom[next] = current
+			heap.Push(open, &pathNode{next, nextCost + octile(next, to), open.pushed})
+		}
+	}
+	return nil
+}
+
+func reconstructPath(cameFrom map[Pos]Pos, from, to Pos) []Pos {
+	var path []Pos
+	for p := to; p != from; p = cameFrom[p] {
+		path = append(path, p)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// octile is the cost of the shortest eight-direction route between two tiles on an open map
+func octile(a, b Pos) float64 {
+	dx := math.Abs(float64(a.X - b.X))
+	dy := math.Abs(float64(a.Y - b.Y))
+	return math.Max(dx, dy) + (math.Sqrt2-1)*math.Min(dx, dy)
+}
+
+type pathNode struct {
+	Pos
+	estimate float64
+	// order breaks ties between equal estimates in the order nodes were found, so that
+	// the same search always returns the same path
+	order int
+}
+
+// pathQueue is a min-heap of nodes by estimated total cost
+type pathQueue struct {
+	nodes  []*pathNode
+	pushed int
+}
+
+func (queue *pathQueue) Len() int {
+	return len(queue.nodes)
+}
+
+func (queue *pathQueue) Less(i, j int) bool {
+	a, b := queue.nodes[i], queue.nodes[j]
+	if a.estimate != b.estimate {
+		return a.estimate < b.estimate
+	}
+	return a.order < b.order
+}
+
+func (queue *pathQueue) Swap(i, j int) {
+	queue.nodes[i], queue.nodes[j] = queue.nodes[j], queue.nodes[i]
+}
+
+func (queue *pathQueue) Push(x interface{}) {
+	queue.nodes = append(queue.nodes, x.(*pathNode))
+	queue.pushed++
+}
+
+func (queue *pathQueue) Pop() interface{} {
+	last := queue.nodes[len(queue.nodes)-1]
+	queue.nodes = queue.nodes[:len(queue.nodes)-1]
+	return last
+}
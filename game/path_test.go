@@ -0,0 +1,84 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+// gridPassable reads a map drawn as rows of text, where '#' cannot be entered; everything
+// outside the rows cannot be entered either
+func gridPassable(rows ...string) func(Pos) bool {
+	return func(p Pos) bool {
+		return p.Y >= 0 && p.Y < len(rows) && p.X >= 0 && p.X < len(rows[p.Y]) && rows[p.Y][p.X] != '#'
+	}
+}
+
+func TestFindPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		rows     []string
+		from, to Pos
+		// cost is the length of the cheapest path in tiles, or -1 if there should be none
+		cost float64
+	}{
+		{"straight", []string{"....."}, Pos{0, 0}, Pos{4, 0}, 4},
+		{"diagonal", []string{"...", "...", "..."}, Pos{0, 0}, Pos{2, 2}, 2 * math.Sqrt2},
+		{"already there", []string{"..."}, Pos{1, 0}, Pos{1, 0}, 0},
+		{"around a wall", []string{
+			".....",
+			".###.",
+			".....",
+		}, Pos{2, 0}, Pos{2, 2}, 6},
+		{"no corner cutting", []string{
+			".#",
+			"..",
+		}, Pos{0, 0}, Pos{1, 1}, 2},
+		{"walled off", []string{
+			"..#..",
+			"..#..",
+		}, Pos{0, 0}, Pos{4, 1}, -1},
+		{"goal blocked", []string{"..#"}, Pos{0, 0}, Pos{2, 0}, -1},
+		{"start blocked", []string{"#.."}, Pos{0, 0}, Pos{2, 0}, 2},
+	}
+	tileMap := &TileMap{}
+	for _, test := range tests {
+		passable := gridPassable(test.rows...)
+		path := tileMap.FindPath(test.from, test.to, passable)
+		if test.cost < 0 {
+			if path != nil {
+				t.Errorf("%s: got path %v, want none", test.name, path)
+			}
+			continue
+		}
+		if path == nil {
+			t.Errorf("%s: got no path", test.name)
+			continue
+		}
+		cost := 0.0
+		at := test.from
+		for _, p := range path {
+			dx, dy := p.X-at.X, p.Y-at.Y
+			if dx < -1 || dx > 1 || dy < -1 || dy > 1 || (dx == 0 && dy == 0) {
+				t.Errorf("%s: path jumps from %v to %v", test.name, at, p)
+			}
+			if !passable(p) {
+				t.Errorf("%s: path enters %v", test.name, p)
+			}
+			if dx != 0 && dy != 0 {
+				if !passable(Pos{at.X + dx, at.Y}) || !passable(Pos{at.X, at.Y + dy}) {
+					t.Errorf("%s: path cuts a corner from %v to %v", test.name, at, p)
+				}
+				cost += math.Sqrt2
+			} else {
+				cost++
+			}
+			at = p
+		}
+		if at != test.to {
+			t.Errorf("%s: path ends at %v, want %v", test.name, at, test.to)
+		}
+		if math.Abs(cost-test.cost) > 1e-9 {
+			t.Errorf("%s: path %v costs %v, want %v", test.name, path, cost, test.cost)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package game
+
+import "fmt"
+
+// Projectiles that are not given a range or lifetime of their own get these, in pixels and seconds
+const (
+	DefaultBulletRange    = 1600.0
+	DefaultBulletLifetime = 4.0
+)
+
+// Expiry is what a projectile does when it reaches the end of its range or lifetime without hitting anything
+type Expiry int
+
+const (
+	// ExpireFizzle makes the projectile vanish in a puff of smoke
+	ExpireFizzle Expiry = iota
+	// ExpireAirburst sets off the projectile's blast where it stops, if it has one
+	ExpireAirburst
+)
+
+var expiryNames = [...]string{"fizzle", "airburst"}
+
+func (expiry Expiry) String() string {
+	if expiry < 0 || int(expiry) >= len(expiryNames) {
+		return "unknown"
+	}
+	return expiryNames[expiry]
+}
+
+// UnmarshalText lets data files name expiries
+func (expiry *Expiry) UnmarshalText(text []byte) error {
+	for i, name := range expiryNames {
+		if string(text) == name {
+			*expiry = Expiry(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown expiry %q", text)
+}
+
+// expireBullets ends every bullet that has used up its range or lifetime without hitting anything.
+// It runs after collisions so that a bullet can still hit something on its final stretch.
+func (level *Level) expireBullets() {
+	for _, bullet := range level.Bullets {
+		if bullet.IsColliding || bullet.Expired {
+			continue
+		}
+		if bullet.Travelled < bullet.Range && bullet.Age < bullet.Lifetime {
+			continue
+		}
+		bullet.Expired = true
+		if bullet.Expire == ExpireAirburst && bullet.Blast.Radius > 0 {
+			level.Explode(bullet.Vec, bullet.Damage, bullet.Blast, bullet.FiredByEnemy)
+		}
+	}
+}
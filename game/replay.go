@@ -0,0 +1,106 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ReplayVersion is bumped whenever a change to the simulation or the file layout would make
+// older replays play back differently. Changes to the data files are caught by the replay's DataHash instead.
+const ReplayVersion = 3
+
+// A Replay is everything needed to reproduce a session: the starting conditions and every
+// input the simulation applied, stamped with the tick it was applied on
+type Replay struct {
+	Version  int    `json:"version"`
+	Seed     int64  `json:"seed"`
+	Level    string `json:"level"`
+	TickRate int    `json:"tickRate"`
+	// DataHash fingerprints the data files the session was played with
+	DataHash string        `json:"dataHash"`
+	Inputs   []ReplayInput `json:"inputs"`
+}
+
+type ReplayInput struct {
+	Tick    int       `json:"tick"`
+	Type    InputType `json:"type"`
+	Pressed bool      `json:"pressed"`
+	X       int       `json:"x"`
+	Y       int       `json:"y"`
+	Option  int       `json:"option,omitempty"`
+}
+
+func (game *Game) NewReplay() *Replay {
+	return &Replay{
+		Version:  ReplayVersion,
+		Seed:     game.Seed,
+		Level:    game.Level.Name,
+		TickRate: game.TickRate,
+		DataHash: game.DataHash,
+	}
+}
+
+func (replay *Replay) record(tick int, input *Input) {
+	replay.Inputs = append(replay.Inputs, ReplayInput{tick, input.Type, input.Pressed, input.X, input.Y, input.Option})
+}
+
+func LoadReplay(filename string) (*Replay, error) {
+	infile, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer infile.Close()
+
+	replay := &Replay{}
+	if err := json.NewDecoder(infile).Decode(replay); err != nil {
+		return nil, fmt.Errorf("reading replay %s: %v", filename, err)
+	}
+	if replay.Version != ReplayVersion {
+		return nil, fmt.Errorf("replay %s has version %d, this build plays version %d", filename, replay.Version, ReplayVersion)
+	}
+	if replay.TickRate <= 0 {
+		return nil, fmt.Errorf("replay %s has tick rate %d, which is not positive", filename, replay.TickRate)
+	}
+	return replay, nil
+}
+
+func (replay *Replay) Save(filename string) error {
+	outfile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(outfile).Encode(replay); err != nil {
+		outfile.Close()
+		return err
+	}
+	return outfile.Close()
+}
+
+// Play makes Run take its inputs from replay instead of InputChan. Only a Quit is still
+// read from InputChan, so the window can be closed while a replay is running.
+func (game *Game) Play(replay *Replay) error {
+	if replay.Level != game.Level.Name {
+		return fmt.Errorf("replay was recorded on level %q, not %q", replay.Level, game.Level.Name)
+	}
+	if replay.DataHash != game.DataHash {
+		return fmt.Errorf("replay was recorded with different game data")
+	}
+	game.TickRate = replay.TickRate
+	game.playback = replay
+	game.playbackIndex = 0
+	return nil
+}
+
+// playInput applies every replayed input that was recorded for the tick about to run
+func (game *Game) playInput() {
+	if game.playback == nil {
+		return
+	}
+	inputs := game.playback.Inputs
+	for game.playbackIndex < len(inputs) && inputs[game.playbackIndex].Tick <= game.Level.Tick {
+		recorded := inputs[game.playbackIndex]
+		game.handleInput(&Input{Pos{recorded.X, recorded.Y}, recorded.Type, recorded.Pressed, recorded.Option})
+		game.playbackIndex++
+	}
+}
@@ -0,0 +1,109 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// session is a scripted run of inputs, keyed by the tick they are sent before
+var session = map[int][]*Input{
+	0: {
+		{Type: ToggleBuild, Pressed: true},
+		{Pos: Pos{300, 300}, Type: Aim},
+		{Type: FirePrimary, Pressed: true},
+		{Type: FirePrimary, Pressed: false},
+		{Pos: Pos{1600, 900}, Type: Aim},
+		{Type: FirePrimary, Pressed: true},
+		{Type: FirePrimary, Pressed: false},
+		{Type: ToggleBuild, Pressed: true},
+	},
+	30:   {{Type: CycleTargetPolicy, Pressed: true}},
+	60:   {{Type: Right, Pressed: true}, {Type: FirePrimary, Pressed: true}},
+	240:  {{Pos: Pos{1200, 200}, Type: Aim}, {Type: Right, Pressed: false}, {Type: Down, Pressed: true}},
+	420:  {{Type: Down, Pressed: false}, {Type: FirePrimary, Pressed: false}, {Type: FireSecondary, Pressed: true}},
+	600:  {{Type: SelectSecondary, Pressed: true, Option: 1}},
+	900:  {{Type: FireSecondary, Pressed: false}, {Type: Left, Pressed: true}},
+	1200: {{Type: Left, Pressed: false}, {Pos: Pos{1600, 900}, Type: Aim}, {Type: UpgradeTower, Pressed: true}},
+}
+
+// step runs the simulation the way Run does, without the ticker or the snapshot channel
+func step(game *Game, ticks int, inputs map[int][]*Input) {
+	for i := 0; i < ticks; i++ {
+		for _, input := range inputs[i] {
+			game.InputChan <- input
+		}
+		game.drainInput()
+		game.playInput()
+		game.tick()
+	}
+}
+
+func TestReplayReproducesSession(t *testing.T) {
+	const ticks = 3000
+	recorded := newTestGame(t, 42)
+	recorded.Recording = recorded.NewReplay()
+	step(recorded, ticks, session)
+	if len(recorded.Level.Towers) == 0 {
+		t.Fatal("the session built no towers, so it proves little")
+	}
+
+	filename := filepath.Join(t.TempDir(), "session.json")
+	if err := recorded.Recording.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	replay, err := LoadReplay(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	played := newTestGame(t, replay.Seed)
+	if err := played.Play(replay); err != nil {
+		t.Fatal(err)
+	}
+	// Live input is ignored while a replay plays
+	step(played, ticks, map[int][]*Input{10: {{Type: Up, Pressed: true}}})
+
+	if !reflect.DeepEqual(recorded.Level.Snapshot(), played.Level.Snapshot()) {
+		t.Errorf("replayed snapshot differs from the recorded one at tick %d", played.Level.Tick)
+	}
+	if recorded.Level.Ledger.Balance() != played.Level.Ledger.Balance() {
+		t.Errorf("replayed balance is %d, recorded %d", played.Level.Ledger.Balance(), recorded.Level.Ledger.Balance())
+	}
+	if recorded.Level.Rand.Int63() != played.Level.Rand.Int63() {
+		t.Error("replay drew a different number of random values")
+	}
+}
+
+func TestPlayRejectsOtherData(t *testing.T) {
+	recorded := newTestGame(t, 1)
+	replay := recorded.NewReplay()
+	replay.DataHash = "something else"
+	if err := newTestGame(t, 1).Play(replay); err == nil {
+		t.Error("Play accepted a replay recorded with different data")
+	}
+}
+
+func TestLoadReplayRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+	}{
+		{"older version", `{"version": 1, "tickRate": 60}`},
+		{"no tick rate", fmt.Sprintf(`{"version": %d}`, ReplayVersion)},
+		{"negative tick rate", fmt.Sprintf(`{"version": %d, "tickRate": -60}`, ReplayVersion)},
+		{"not json", `{"version": `},
+	}
+	dir := t.TempDir()
+	for i, test := range tests {
+		filename := filepath.Join(dir, fmt.Sprintf("replay%d.json", i))
+		if err := os.WriteFile(filename, []byte(test.json), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadReplay(filename); err == nil {
+			t.Errorf("%s: LoadReplay accepted it", test.name)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package game
+
+// A Snapshot is a read-only copy of everything the renderer needs to draw one tick.
+// It shares no memory with the Level it was taken from, so it can be handed to another goroutine.
+type Snapshot struct {
+	Tick int
+	// Camera is the world position of the top left corner of the view; everything else is in world coordinates
+	Camera Vec
+	// Ground holds the tiles the view overlaps, and Obstacles the props standing on them
+	Ground    []Sprite
+	Obstacles []Sprite
+	Player    Sprite
+	Base      Sprite
+	Enemies   []Sprite
+	// Towers holds each tower's base followed by its barrel
+	Towers  []Sprite
+	Bullets []Sprite
+	Effects []Effect
+	Build   BuildPreview
+	Lock    LockOn
+	HUD     HUD
+	// Outcome tells the renderer when to show the victory or defeat screen
+	Outcome Outcome
+}
+
+// Sprite is an entity centered on Vec with its image rotated by Direction degrees.
+// Sprite images point down, so a Direction of 0 faces along the positive y axis.
+type Sprite struct {
+	Vec
+	Size
+	ID        SpriteID
+	Direction float64
+}
+
+type EffectType int
+
+const (
+	MuzzleFlash EffectType = iota
+	Impact
+	Explosion
+	// Fizzle is a projectile petering out at the end of its range
+	Fizzle
+)
+
+// Effect is a short animation centered on Vec, currently showing Frame out of Frames
+type Effect struct {
+	Vec
+	Type      EffectType
+	Direction float64
+	Frame     int
+	Frames    int
+	// Size, when set, is the width in pixels the effect should cover
+	Size float64
+}
+
+// BuildPreview is the tower that would be placed where the cursor is, while in build mode
+type BuildPreview struct {
+	Active bool
+	Sprite
+	// Valid is false when the tile is taken or the player cannot afford a tower
+	Valid bool
+}
+
+// LockOn marks the enemy the homing weapon in hand would lock onto if fired now
+type LockOn struct {
+	Active bool
+	Vec
+	Size
+}
+
+type HUD struct {
+	Hitpoints int
+	// Lives is how many more enemies may leak into the goal
+	Lives int
+	// BaseHitpoints is what is left of the base's BaseMaxHitpoints
+	BaseHitpoints, BaseMaxHitpoints int
+	Currency                        int
+	// Wave is the number of the latest wave to start, out of Waves
+	Wave, Waves int
+	// Countdown is the seconds until the next wave, or 0 if none is on its way
+	Countdown float64
+	// Transactions are the changes to Currency since the previous snapshot the renderer received,
+	// oldest first, for it to call out
+	Transactions []LedgerEvent
+	Seed         int64
+	// TickRate is how many ticks the game runs per second, for timing things by Tick
+	TickRate int
+	// Tower describes the tower under the cursor, if there is one
+	Tower TowerInfo
+	// Weapons lists the player's secondary weapons in hotkey order
+	Weapons []WeaponInfo
+}
+
+type WeaponInfo struct {
+	Name string
+	// Ammo is the shots left, or -1 for a weapon that never runs out
+	Ammo     int
+	Ready    bool
+	Selected bool
+}
+
+type TowerInfo struct {
+	Active    bool
+	Name      string
+	Level     int
+	Policy    string
+	SellValue int
+	// Upgrades lists the upgrade tree branches open to the tower, in option order
+	Upgrades []UpgradeInfo
+}
+
+type UpgradeInfo struct {
+	Name string
+	Cost int
+	// Affordable is whether the player has the currency for it
+	Affordable bool
+}
+
+// spriteOf draws entity pointing along facing
+func spriteOf(entity *Entity, facing Vec) Sprite {
+	return Sprite{entity.Vec, entity.Size, entity.Sprite, facing.Angle() - 90}
+}
+
+// Snapshot copies the current state of the level for rendering
+func (level *Level) Snapshot() *Snapshot {
+	snapshot := &Snapshot{}
+	snapshot.Tick = level.Tick
+	snapshot.Camera = level.Camera
+	snapshot.Ground, snapshot.Obstacles = level.visibleGround()
+
+	player := level.Player
+	snapshot.Player = spriteOf(&player.Entity, player.Facing)
+	snapshot.HUD.Hitpoints = player.Hitpoints
+	snapshot.HUD.Lives = level.Lives
+	snapshot.Base = spriteOf(&level.Base.Entity, level.Base.Facing)
+	snapshot.HUD.BaseHitpoints = level.Base.Hitpoints
+	snapshot.HUD.BaseMaxHitpoints = level.Base.MaxHitpoints
+	snapshot.Outcome = level.Outcome
+	snapshot.HUD.Currency = level.Ledger.Balance()
+	for i, slot := range player.Secondary {
+		ammo := slot.Ammo
+		if slot.Spec.Ammo == 0 {
+			ammo = -1
+		}
+		snapshot.HUD.Weapons = append(snapshot.HUD.Weapons, WeaponInfo{slot.Spec.Name, ammo, slot.Ready(), i == player.Selected})
+	}
+	snapshot.HUD.Wave = level.Waves.Current
+	snapshot.HUD.Waves = len(level.Waves.Waves)
+	if level.Waves.Waiting() {
+		snapshot.HUD.Countdown = level.Waves.Countdown
+	}
+
+	snapshot.Towers = make([]Sprite, 0, 2*len(level.Towers))
+	for _, tower := range level.Towers {
+		snapshot.Towers = append(snapshot.Towers, spriteOf(&tower.Entity, Vec{0, 1}), tower.barrelSprite(level))
+	}
+	if secondary := player.SecondaryWeapon(); secondary != nil && secondary.Spec.TurnRate > 0 {
+		if target := level.LockTarget(secondary.Spec); target != nil {
+			snapshot.Lock = LockOn{true, target.Vec, target.Size}
+		}
+	}
+	if tower := level.towerAt(player.Aim); tower != nil {
+		snapshot.HUD.Tower = level.towerInfo(tower)
+	}
+	if level.BuildMode {
+		tile := level.Map.TileAt(player.Aim)
+		tower := level.InitTower(level.TowerCatalog.BuildSpec(), tile)
+		snapshot.Build = BuildPreview{true, spriteOf(&tower.Entity, Vec{0, 1}), level.CanBuild(tile)}
+	}
+
+	snapshot.Enemies = make([]Sprite, 0, len(level.Enemies))
+	for _, enemy := range level.Enemies {
+		if enemy.IsDestroyed {
+			snapshot.Effects = append(snapshot.Effects, Effect{
+				Vec:    enemy.Vec,
+				Type:   Explosion,
+				Frame:  enemy.DestroyedAnimationCounter,
+				Frames: EnemyExplodeTicks,
+			})
+			continue
+		}
+		snapshot.Enemies = append(snapshot.Enemies, spriteOf(&enemy.Entity, enemy.Facing))
+	}
+
+	for _, detonation := range level.Detonations {
+		snapshot.Effects = append(snapshot.Effects, Effect{
+			Vec:    detonation.Vec,
+			Type:   Explosion,
+			Frame:  detonation.Counter,
+			Frames: BlastTicks,
+			Size:   2 * detonation.Radius,
+		})
+	}
+
+	snapshot.Bullets = make([]Sprite, 0, len(level.Bullets))
+	for _, bullet := range level.Bullets {
+		if !bullet.FireAnimationPlayed {
+			snapshot.Effects = append(snapshot.Effects, Effect{
+				Vec:       bullet.FiredBy.Muzzle(),
+				Type:      MuzzleFlash,
+				Direction: bullet.Facing.Angle() - 90,
+				Frame:     bullet.FlashCounter,
+				Frames:    BulletFlashTicks,
+			})
+		}
+		if bullet.Expired {
+			if bullet.Expire == ExpireFizzle {
+				snapshot.Effects = append(snapshot.Effects, Effect{
+					Vec:    bullet.Vec,
+					Type:   Fizzle,
+					Frame:  bullet.ExplodeCounter,
+					Frames: BulletExplodeTicks,
+				})
+			}
+			continue
+		}
+		if bullet.IsColliding {
+			snapshot.Effects = append(snapshot.Effects, Effect{
+				Vec:       bullet.Vec,
+				Type:      Impact,
+				Direction: bullet.Facing.Angle() - 90,
+				Frame:     bullet.ExplodeCounter,
+				Frames:    BulletExplodeTicks,
+			})
+			continue
+		}
+		// Bullet images point up, the opposite way to the tanks
+		snapshot.Bullets = append(snapshot.Bullets, spriteOf(&bullet.Entity, bullet.Facing.Scale(-1)))
+	}
+
+	return snapshot
+}
+
+func (level *Level) towerInfo(tower *Tower) TowerInfo {
+	info := TowerInfo{true, tower.Spec.Name, tower.Level, tower.Policy.String(), level.SellValue(tower), nil}
+	for i := range tower.Spec.Upgrades {
+		next := level.UpgradeOption(tower, i)
+		info.Upgrades = append(info.Upgrades, UpgradeInfo{next.Name, next.Cost, level.Ledger.CanAfford(next.Cost)})
+	}
+	return info
+}
+
+// visibleGround lists a sprite for every map tile the view overlaps, and for every obstacle on them
+func (level *Level) visibleGround() (ground, obstacles []Sprite) {
+	min := level.Map.TileAt(level.Camera)
+	max := level.Map.TileAt(level.Camera.Add(Vec{float64(level.View.W - 1), float64(level.View.H - 1)}))
+	ground = make([]Sprite, 0, (max.X-min.X+1)*(max.Y-min.Y+1))
+	for y := min.Y; y <= max.Y; y++ {
+		for x := min.X; x <= max.X; x++ {
+			p := Pos{x, y}
+			if tile := level.Map.At(p); tile != nil {
+				ground = append(ground, Sprite{level.Map.Center(p), Size{TileSize, TileSize}, tile.Sprite, 0})
+				if tile.Obstacle != "" {
+					obstacles = append(obstacles, Sprite{level.Map.Center(p), level.Sprites[tile.Obstacle], tile.Obstacle, 0})
+				}
+			}
+		}
+	}
+	return ground, obstacles
+}
@@ -0,0 +1,111 @@
+package game
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultCellSize matches the ground tiles, which is a few times the size of a tank
+const DefaultCellSize = 128.0
+
+// SpatialHash is a uniform grid broadphase. Objects are filed under every cell their bounds
+// touch, so a query only has to test the objects in the cells its own bounds touch.
+type SpatialHash struct {
+	CellSize float64
+	cells    map[cell][]Dimensional
+	// seen marks the objects already found by the query in progress; it is emptied after each one
+	seen map[Dimensional]struct{}
+}
+
+type cell struct {
+	X, Y int
+}
+
+func NewSpatialHash(cellSize float64) *SpatialHash {
+	return &SpatialHash{CellSize: cellSize, cells: make(map[cell][]Dimensional), seen: make(map[Dimensional]struct{})}
+}
+
+// Clear empties the hash while keeping its cells allocated for the next tick
+func (hash *SpatialHash) Clear() {
+	for key, objs := range hash.cells {
+		hash.cells[key] = objs[:0]
+	}
+}
+
+func (hash *SpatialHash) Insert(obj Dimensional) {
+	min, max := obj.GetShape().Bounds()
+	minCell, maxCell := hash.cellOf(min), hash.cellOf(max)
+	for y := minCell.Y; y <= maxCell.Y; y++ {
+		for x := minCell.X; x <= maxCell.X; x++ {
+			key := cell{x, y}
+			hash.cells[key] = append(hash.cells[key], obj)
+		}
+	}
+}
+
+func (hash *SpatialHash) cellOf(p Vec) cell {
+	return cell{int(math.Floor(p.X / hash.CellSize)), int(math.Floor(p.Y / hash.CellSize))}
+}
+
+// candidates lists every object sharing a cell with the given bounds, each once,
+// in a stable order so that results do not depend on map iteration
+func (hash *SpatialHash) candidates(min, max Vec) []Dimensional {
+	var found []Dimensional
+	minCell, maxCell := hash.cellOf(min), hash.cellOf(max)
+	for y := minCell.Y; y <= maxCell.Y; y++ {
+		for x := minCell.X; x <= maxCell.X; x++ {
+			for _, obj := range hash.cells[cell{x, y}] {
+				if _, ok := hash.seen[obj]; !ok {
+					hash.seen[obj] = struct{}{}
+					found = append(found, obj)
+				}
+			}
+		}
+	}
+	for _, obj := range found {
+		delete(hash.seen, obj)
+	}
+	return found
+}
+
+// QueryShape returns every object whose shape overlaps shape
+func (hash *SpatialHash) QueryShape(shape Shape) []Dimensional {
+	min, max := shape.Bounds()
+	var hits []Dimensional
+	for _, obj := range hash.candidates(min, max) {
+		if Overlaps(shape, obj.GetShape()) {
+			hits = append(hits, obj)
+		}
+	}
+	return hits
+}
+
+// QueryRadius returns every object whose shape comes within radius of center
+func (hash *SpatialHash) QueryRadius(center Vec, radius float64) []Dimensional {
+	return hash.QueryShape(Shape{Kind: Circle, Center: center, Radius: radius})
+}
+
+// SegmentHit is an object struck by a sweep, T of the way along it at Point
+type SegmentHit struct {
+	Obj   Dimensional
+	T     float64
+	Point Vec
+}
+
+// QuerySegment sweeps a circle of the given radius from from to to and returns everything
+// it touches, nearest first
+func (hash *SpatialHash) QuerySegment(from, to Vec, radius float64) []SegmentHit {
+	pad := Vec{radius, radius}
+	min := Vec{math.Min(from.X, to.X), math.Min(from.Y, to.Y)}.Sub(pad)
+	max := Vec{math.Max(from.X, to.X), math.Max(from.Y, to.Y)}.Add(pad)
+	var hits []SegmentHit
+	for _, obj := range hash.candidates(min, max) {
+		if t, ok := Raycast(from, to, radius, obj.GetShape()); ok {
+			hits = append(hits, SegmentHit{obj, t, from.Add(to.Sub(from).Scale(t))})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].T < hits[j].T
+	})
+	return hits
+}
@@ -0,0 +1,114 @@
+package game
+
+import "testing"
+
+type testObj struct {
+	shape Shape
+}
+
+func (obj *testObj) GetShape() Shape {
+	return obj.shape
+}
+
+func TestQueryShape(t *testing.T) {
+	small := &testObj{circle(100, 100, 10)}
+	// Spans several cells, so it is filed more than once and must still be found only once
+	wide := &testObj{box(400, 100, 400, 20)}
+	far := &testObj{box(2000, 2000, 40, 40)}
+	hash := NewSpatialHash(DefaultCellSize)
+	for _, obj := range []*testObj{small, wide, far} {
+		hash.Insert(obj)
+	}
+
+	tests := []struct {
+		name  string
+		shape Shape
+		want  []*testObj
+	}{
+		{"around the small one", circle(100, 100, 20), []*testObj{small}},
+		{"same cell, no overlap", circle(100, 60, 20), nil},
+		{"across both", box(250, 100, 400, 40), []*testObj{small, wide}},
+		{"along the wide one", box(500, 100, 500, 2), []*testObj{wide}},
+		{"far away", circle(2000, 2000, 1), []*testObj{far}},
+		{"nowhere", circle(-1000, -1000, 50), nil},
+	}
+	for _, test := range tests {
+		hits := hash.QueryShape(test.shape)
+		if len(hits) != len(test.want) {
+			t.Errorf("%s: got %d hits, want %d", test.name, len(hits), len(test.want))
+			continue
+		}
+		for _, want := range test.want {
+			found := false
+			for _, hit := range hits {
+				found = found || hit == want
+			}
+			if !found {
+				t.Errorf("%s: missed the object at %v", test.name, want.shape.Center)
+			}
+		}
+	}
+}
+
+func TestQueryRadiusFindsEachObjectOnce(t *testing.T) {
+	hash := NewSpatialHash(DefaultCellSize)
+	wide := &testObj{box(0, 0, 1000, 1000)}
+	hash.Insert(wide)
+	hits := hash.QueryRadius(Vec{0, 0}, 600)
+	if len(hits) != 1 || hits[0] != wide {
+		t.Errorf("got %d hits, want only the one object", len(hits))
+	}
+}
+
+func TestClearEmptiesHash(t *testing.T) {
+	hash := NewSpatialHash(DefaultCellSize)
+	hash.Insert(&testObj{circle(0, 0, 10)})
+	hash.Clear()
+	if hits := hash.QueryRadius(Vec{0, 0}, 100); len(hits) != 0 {
+		t.Errorf("got %d hits after Clear, want none", len(hits))
+	}
+}
+
+func TestQuerySegment(t *testing.T) {
+	near := &testObj{circle(200, 0, 10)}
+	far := &testObj{box(600, 0, 40, 40)}
+	// Spans several cells, so it is filed more than once and must still be hit only once
+	wide := &testObj{box(400, 0, 400, 20)}
+	beside := &testObj{circle(300, 60, 10)}
+	hash := NewSpatialHash(DefaultCellSize)
+	for _, obj := range []*testObj{far, beside, wide, near} {
+		hash.Insert(obj)
+	}
+
+	tests := []struct {
+		name     string
+		from, to Vec
+		radius   float64
+		want     []*testObj
+	}{
+		{"along the row", Vec{0, 0}, Vec{1000, 0}, 0, []*testObj{near, wide, far}},
+		{"back along the row", Vec{1000, 0}, Vec{0, 0}, 0, []*testObj{far, wide, near}},
+		{"stops short", Vec{0, 0}, Vec{150, 0}, 0, nil},
+		{"wide sweep", Vec{0, 0}, Vec{1000, 0}, 80, []*testObj{near, wide, beside, far}},
+		{"down past everything", Vec{100, -200}, Vec{100, 200}, 0, nil},
+	}
+	for _, test := range tests {
+		hits := hash.QuerySegment(test.from, test.to, test.radius)
+		if len(hits) != len(test.want) {
+			t.Errorf("%s: got %d hits, want %d", test.name, len(hits), len(test.want))
+			continue
+		}
+		for i, hit := range hits {
+			if hit.Obj != test.want[i] {
+				t.Errorf("%s: hit %d is %v, want %v", test.name, i, hit.Obj.GetShape().Center, test.want[i].shape.Center)
+			}
+			if i > 0 && hit.T < hits[i-1].T {
+				t.Errorf("%s: hit %d at t %v comes after t %v", test.name, i, hit.T, hits[i-1].T)
+			}
+			want := test.from.Add(test.to.Sub(test.from).Scale(hit.T))
+			if hit.Point.Dist(want) > 1e-9 {
+				t.Errorf("%s: hit %d is at %v, want %v", test.name, i, hit.Point, want)
+			}
+		}
+	}
+}
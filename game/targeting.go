@@ -0,0 +1,68 @@
+package game
+
+import "math"
+
+// TargetPolicy decides which of the enemies in a tower's range it shoots at
+type TargetPolicy int
+
+const (
+	// TargetNearest picks the enemy closest to the tower
+	TargetNearest TargetPolicy = iota
+	// TargetFirst picks the enemy with the least left to drive to its goal, the one closest to getting past
+	TargetFirst
+	// TargetStrongest picks the enemy with the most hitpoints left
+	TargetStrongest
+	// TargetWeakest picks the enemy with the fewest hitpoints left
+	TargetWeakest
+	// TargetLastHit keeps shooting whichever enemy was struck most recently, so towers focus their fire
+	TargetLastHit
+	targetPolicyCount
+)
+
+var targetPolicyNames = [targetPolicyCount]string{"nearest", "first", "strongest", "weakest", "last hit"}
+
+func (policy TargetPolicy) String() string {
+	if policy < 0 || policy >= targetPolicyCount {
+		return "unknown"
+	}
+	return targetPolicyNames[policy]
+}
+
+// Next is the policy after this one, wrapping around to the first
+func (policy TargetPolicy) Next() TargetPolicy {
+	return (policy + 1) % targetPolicyCount
+}
+
+// SelectTarget picks the live enemy within radius of center that policy prefers, or nil if
+// there is none. Ties go to the nearest enemy.
+func (level *Level) SelectTarget(policy TargetPolicy, center Vec, radius float64) *Enemy {
+	var best *Enemy
+	bestScore, bestDist := math.Inf(-1), math.Inf(1)
+	for _, unit := range level.Units.QueryRadius(center, radius) {
+		enemy, ok := unit.(*Enemy)
+		if !ok || enemy.IsDestroyed {
+			continue
+		}
+		score, dist := policy.score(level, enemy), center.Dist(enemy.Vec)
+		if score > bestScore || (score == bestScore && dist < bestDist) {
+			best, bestScore, bestDist = enemy, score, dist
+		}
+	}
+	return best
+}
+
+// score ranks an enemy under the policy; higher is preferred
+func (policy TargetPolicy) score(level *Level, enemy *Enemy) float64 {
+	switch policy {
+	case TargetFirst:
+		return -enemy.Remaining(level.Map)
+	case TargetStrongest:
+		return float64(enemy.Hitpoints)
+	case TargetWeakest:
+		return -float64(enemy.Hitpoints)
+	case TargetLastHit:
+		return float64(enemy.LastHitTick)
+	default:
+		return 0
+	}
+}
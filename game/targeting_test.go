@@ -0,0 +1,53 @@
+package game
+
+import "testing"
+
+func TestSelectTarget(t *testing.T) {
+	game := newTestGame(t, 1)
+	level := game.Level
+	center := level.Player.Vec.Add(Vec{3000, 0})
+	spawn := func(offset Vec, hitpoints int) *Enemy {
+		at := center.Add(offset)
+		enemy, err := level.SpawnEnemy("heavy", &at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		enemy.Hitpoints = hitpoints
+		return enemy
+	}
+	near := spawn(Vec{100, 0}, 50)
+	near.Path = []Pos{level.Map.TileAt(center.Add(Vec{1500, 0}))}
+	hit := spawn(Vec{0, 200}, 150)
+	hit.LastHitTick = 7
+	leading := spawn(Vec{-300, 0}, 80)
+	leading.Path = []Pos{level.Map.TileAt(leading.Vec)}
+	// As strong as hit, but further away, so it loses the tie
+	spawn(Vec{0, -350}, 150)
+	outOfRange := spawn(Vec{500, 0}, 10)
+	outOfRange.LastHitTick = 99
+	destroyed := spawn(Vec{50, 0}, 1)
+	level.indexUnits()
+	destroyed.IsDestroyed = true
+
+	tests := []struct {
+		policy TargetPolicy
+		want   *Enemy
+	}{
+		{TargetNearest, near},
+		{TargetFirst, leading},
+		{TargetStrongest, hit},
+		{TargetWeakest, near},
+		{TargetLastHit, hit},
+	}
+	for _, test := range tests {
+		got := level.SelectTarget(test.policy, center, 400)
+		if got == nil {
+			t.Errorf("%v: picked nothing, want the enemy at %v", test.policy, test.want.Vec)
+		} else if got != test.want {
+			t.Errorf("%v: picked the enemy at %v, want the one at %v", test.policy, got.Vec, test.want.Vec)
+		}
+	}
+	if got := level.SelectTarget(TargetNearest, center.Add(Vec{0, 2000}), 400); got != nil {
+		t.Errorf("picked the enemy at %v with none in range", got.Vec)
+	}
+}
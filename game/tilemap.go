@@ -0,0 +1,83 @@
+package game
+
+import "math"
+
+// TileSize is the width and height of a ground tile in pixels
+const TileSize = 128
+
+type Tile struct {
+	Sprite SpriteID
+	// IsTrack is set on the road of a lane, which enemies drive along and towers cannot be built on
+	IsTrack bool
+	// roads holds the directions the tile's road connects to
+	roads int
+	// Impassable ground cannot be driven over or built on
+	Impassable bool
+	// Obstacle is a prop standing on the tile, if any, which blocks it like impassable ground
+	Obstacle SpriteID
+	// Tower is the tower built on this tile, if any
+	Tower *Tower
+}
+
+// Open reports whether the tile is free for a unit to drive over or a tower to be built on
+func (tile *Tile) Open() bool {
+	return !tile.Impassable && tile.Obstacle == "" && tile.Tower == nil
+}
+
+// Buildable reports whether a tower may be built on the tile
+func (tile *Tile) Buildable() bool {
+	return tile.Open() && !tile.IsTrack
+}
+
+// TileMap is the ground of a level, addressed as Tiles[y][x]
+type TileMap struct {
+	Width, Height int
+	Tiles         [][]*Tile
+}
+
+// NewTestMap is a width x height checkerboard of grass and sand, dotted with short walls
+// of crates and the odd tree
+func NewTestMap(width, height int) *TileMap {
+	tileMap := &TileMap{Width: width, Height: height}
+	tileMap.Tiles = make([][]*Tile, height)
+	for y := range tileMap.Tiles {
+		tileMap.Tiles[y] = make([]*Tile, width)
+		for x := range tileMap.Tiles[y] {
+			if (x+y)%2 == 0 {
+				tileMap.Tiles[y][x] = &Tile{Sprite: "tileGrass1"}
+			} else {
+				tileMap.Tiles[y][x] = &Tile{Sprite: "tileSand1"}
+			}
+			switch {
+			case x%10 == 3 && y%8 >= 1 && y%8 <= 3:
+				tileMap.Tiles[y][x].Obstacle = "crateMetal"
+			case x%13 == 9 && y%11 == 6:
+				tileMap.Tiles[y][x].Obstacle = "treeGreen_large"
+			}
+		}
+	}
+	return tileMap
+}
+
+// At returns the tile at tile coordinates p, or nil outside the map
+func (tileMap *TileMap) At(p Pos) *Tile {
+	if p.X < 0 || p.Y < 0 || p.X >= tileMap.Width || p.Y >= tileMap.Height {
+		return nil
+	}
+	return tileMap.Tiles[p.Y][p.X]
+}
+
+// TileAt returns the tile coordinates of the tile containing world point v
+func (tileMap *TileMap) TileAt(v Vec) Pos {
+	return Pos{int(math.Floor(v.X / TileSize)), int(math.Floor(v.Y / TileSize))}
+}
+
+// Center is the world point in the middle of the tile at tile coordinates p
+func (tileMap *TileMap) Center(p Pos) Vec {
+	return Vec{(float64(p.X) + 0.5) * TileSize, (float64(p.Y) + 0.5) * TileSize}
+}
+
+// PixelSize is the size of the whole map in world pixels
+func (tileMap *TileMap) PixelSize() Vec {
+	return Vec{float64(tileMap.Width * TileSize), float64(tileMap.Height * TileSize)}
+}
@@ -0,0 +1,176 @@
+package game
+
+import "math"
+
+// Towers turn to face a target before firing, and hold fire until they are within
+// TowerAimTolerance degrees of it
+const TowerAimTolerance = 5.0
+
+type Tower struct {
+	Character
+	FireControl
+	// Spec is the node of the upgrade tree the tower is at
+	Spec *TowerSpec
+	// Tile is the map tile the tower stands on
+	Tile   Pos
+	Barrel SpriteID
+	// Range is how far away, in pixels, the tower can pick a target
+	Range float64
+	// TurnRate is how fast the barrel turns, in degrees per second
+	TurnRate float64
+	Policy   TargetPolicy
+	Target   *Enemy
+}
+
+// Tower implements Shooter, fighting on the player's side
+func (tower *Tower) GetFireSettings() (float64, float64, bool) {
+	return tower.FireTimer, tower.FireCooldown, true
+}
+
+func (tower *Tower) SetFireTimer(value float64) {
+	tower.FireTimer = value
+}
+
+func (tower *Tower) GetSelf() *Character {
+	return &tower.Character
+}
+
+func (level *Level) InitTower(spec *TowerSpec, tile Pos) *Tower {
+	tower := &Tower{}
+	tower.Hitpoints = 100
+	tower.FireTimer = 0
+	tower.Policy = TargetNearest
+	tower.Collider = Box
+	tower.Tile = tile
+	tower.Vec = level.Map.Center(tile)
+	tower.Facing = Vec{0, 1}
+	level.applySpec(tower, spec)
+	tower.Cost = spec.Cost
+	return tower
+}
+
+// applySpec makes tower look and fight the way spec says. Cost is left alone, since it
+// totals everything spent on the tower rather than the price of its latest spec.
+func (level *Level) applySpec(tower *Tower, spec *TowerSpec) {
+	tower.Spec = spec
+	tower.Level = spec.Level
+	tower.Sprite = spec.Sprite
+	tower.Barrel = spec.Barrel
+	tower.BulletSprite = spec.Bullet
+	tower.Strength = spec.Damage
+	tower.FireCooldown = spec.Cooldown
+	tower.Range = spec.Range
+	tower.TurnRate = spec.TurnRate
+	tower.Size = level.Sprites[tower.Sprite]
+	tower.FireOffset = float64(level.Sprites[tower.Barrel].H)
+}
+
+// CanBuild reports whether a tower may be placed on the tile at tile coordinates p
+func (level *Level) CanBuild(p Pos) bool {
+	tile := level.Map.At(p)
+	return tile != nil && tile.Buildable() && level.Ledger.CanAfford(level.TowerCatalog.BuildSpec().Cost)
+}
+
+// BuildTower places a tower on the tile under the world point at, paid for by the player.
+// It returns nil if the tile cannot be built on or the player cannot afford it.
+func (level *Level) BuildTower(at Vec) *Tower {
+	p := level.Map.TileAt(at)
+	tile := level.Map.At(p)
+	if tile == nil || !tile.Buildable() {
+		return nil
+	}
+	spec := level.TowerCatalog.BuildSpec()
+	if level.Ledger.Spend(level.Tick, spec.Cost, TowerBuild) != nil {
+		return nil
+	}
+	tower := level.InitTower(spec, p)
+	tile.Tower = tower
+	level.Towers = append(level.Towers, tower)
+	return tower
+}
+
+// UpgradeOption is the spec tower would become by taking its option'th upgrade, or nil if it has no such upgrade
+func (level *Level) UpgradeOption(tower *Tower, option int) *TowerSpec {
+	if option < 0 || option >= len(tower.Spec.Upgrades) {
+		return nil
+	}
+	return level.TowerCatalog.Towers[tower.Spec.Upgrades[option]]
+}
+
+// UpgradeTower moves tower one level up its upgrade tree along the given option, paid for
+// by the player, and reports whether it could
+func (level *Level) UpgradeTower(tower *Tower, option int) bool {
+	next := level.UpgradeOption(tower, option)
+	if next == nil || next.Level != tower.Level+1 {
+		return false
+	}
+	if level.Ledger.Spend(level.Tick, next.Cost, TowerUpgrade) != nil {
+		return false
+	}
+	tower.Cost += next.Cost
+	level.applySpec(tower, next)
+	return true
+}
+
+// SellValue is what the player gets back for selling tower
+func (level *Level) SellValue(tower *Tower) int {
+	return int(float64(tower.Cost) * level.TowerCatalog.SellRefund)
+}
+
+// SellTower removes tower from the map and refunds part of what it cost
+func (level *Level) SellTower(tower *Tower) {
+	level.Ledger.Earn(level.Tick, level.SellValue(tower), TowerSale)
+	level.Map.At(tower.Tile).Tower = nil
+	for i, t := range level.Towers {
+		if t == tower {
+			level.Towers = append(level.Towers[:i], level.Towers[i+1:]...)
+			break
+		}
+	}
+}
+
+func (tower *Tower) Update(level *Level, dt float64) {
+	tower.FireControl.Update(dt)
+	tower.Target = level.SelectTarget(tower.Policy, tower.Vec, tower.Range)
+	if tower.Target == nil {
+		return
+	}
+	toTarget := LeadDirection(tower.Vec, tower.Target.Vec, tower.Target.Vel, DefaultBulletSpeed)
+	if !tower.turnToward(toTarget, dt) {
+		return
+	}
+	if bullet := level.CheckFiring(tower); bullet != nil {
+		bullet.Blast = tower.Spec.Blast
+		bullet.Expire = tower.Spec.Expire
+		if tower.Spec.MissileTurnRate > 0 {
+			bullet.TurnRate = tower.Spec.MissileTurnRate
+			bullet.Target = tower.Target
+		}
+	}
+}
+
+// barrelSprite draws the barrel pivoting on the tower's center, pointing along its facing
+func (tower *Tower) barrelSprite(level *Level) Sprite {
+	size := level.Sprites[tower.Barrel]
+	center := tower.Vec.Add(tower.Facing.Scale(float64(size.H) / 2))
+	return Sprite{center, size, tower.Barrel, tower.Facing.Angle() - 90}
+}
+
+// turnToward turns the tower's facing toward dir by no more than its turn rate allows this
+// step, and reports whether it ended up close enough to fire
+func (tower *Tower) turnToward(dir Vec, dt float64) bool {
+	if dir == (Vec{}) {
+		return false
+	}
+	tower.Facing = tower.Facing.TurnToward(dir, tower.TurnRate*dt)
+	return math.Abs(tower.Facing.AngleTo(dir)) <= TowerAimTolerance
+}
+
+// towerAt returns the tower standing on the tile under the world point at, if any
+func (level *Level) towerAt(at Vec) *Tower {
+	tile := level.Map.At(level.Map.TileAt(at))
+	if tile == nil {
+		return nil
+	}
+	return tile.Tower
+}
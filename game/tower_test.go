@@ -0,0 +1,87 @@
+package game
+
+import "testing"
+
+// buildableTile finds a tile a tower can be built on
+func buildableTile(t *testing.T, tileMap *TileMap) Pos {
+	t.Helper()
+	for y := 0; y < tileMap.Height; y++ {
+		for x := 0; x < tileMap.Width; x++ {
+			if tileMap.At(Pos{x, y}).Buildable() {
+				return Pos{x, y}
+			}
+		}
+	}
+	t.Fatal("no tile can be built on")
+	return Pos{}
+}
+
+func TestUpgradeAndSellTower(t *testing.T) {
+	game := newTestGame(t, 1)
+	level := game.Level
+	level.Ledger = NewLedger(0, 200)
+	level.TowerCatalog = &TowerCatalog{Build: "gun", SellRefund: 0.75, Towers: map[string]*TowerSpec{
+		"gun": {Name: "gun", Level: 1, Cost: 50, Upgrades: []string{"big", "skip"}},
+		"big": {Name: "big", Level: 2, Cost: 75, Upgrades: []string{"huge"}},
+		// Not a valid catalog, so that the level check has something to catch
+		"skip": {Name: "skip", Level: 3, Cost: 10},
+		"huge": {Name: "huge", Level: 3, Cost: 150},
+	}}
+
+	p := buildableTile(t, level.Map)
+	tower := level.BuildTower(level.Map.Center(p))
+	if tower == nil {
+		t.Fatalf("could not build on %v", p)
+	}
+	check := func(step string, spec string, cost, balance int) {
+		t.Helper()
+		if tower.Spec.Name != spec || tower.Level != level.TowerCatalog.Towers[spec].Level {
+			t.Errorf("%s: tower is %s at level %d, want %s", step, tower.Spec.Name, tower.Level, spec)
+		}
+		if tower.Cost != cost {
+			t.Errorf("%s: tower cost %d in all, want %d", step, tower.Cost, cost)
+		}
+		if level.Ledger.Balance() != balance {
+			t.Errorf("%s: balance is %d, want %d", step, level.Ledger.Balance(), balance)
+		}
+	}
+	check("built", "gun", 50, 150)
+
+	if level.UpgradeTower(tower, 1) {
+		t.Error("upgraded two levels at once")
+	}
+	if level.UpgradeTower(tower, 2) || level.UpgradeTower(tower, -1) {
+		t.Error("upgraded along an option that does not exist")
+	}
+	check("refused upgrades", "gun", 50, 150)
+
+	if !level.UpgradeTower(tower, 0) {
+		t.Fatal("could not upgrade to big")
+	}
+	check("upgraded", "big", 125, 75)
+
+	if level.UpgradeTower(tower, 0) {
+		t.Error("upgraded without the money for it")
+	}
+	if last := level.Ledger.History[len(level.Ledger.History)-1]; !last.Rejected || last.Reason != TowerUpgrade {
+		t.Errorf("last ledger event is %+v, want a rejected upgrade", last)
+	}
+	check("unaffordable upgrade", "big", 125, 75)
+
+	level.Ledger.Earn(0, 100, KillBounty)
+	if !level.UpgradeTower(tower, 0) {
+		t.Fatal("could not upgrade to huge")
+	}
+	check("upgraded again", "huge", 275, 25)
+
+	if got := level.SellValue(tower); got != 206 {
+		t.Errorf("tower sells for %d, want 206", got)
+	}
+	level.SellTower(tower)
+	if level.Ledger.Balance() != 231 {
+		t.Errorf("balance after the sale is %d, want 231", level.Ledger.Balance())
+	}
+	if len(level.Towers) != 0 || level.Map.At(p).Tower != nil {
+		t.Error("sold tower is still on the map")
+	}
+}
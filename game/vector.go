@@ -0,0 +1,88 @@
+package game
+
+import "math"
+
+// Vec is a point or a displacement in world space, measured in pixels
+type Vec struct {
+	X, Y float64
+}
+
+func (v Vec) Add(o Vec) Vec {
+	return Vec{v.X + o.X, v.Y + o.Y}
+}
+
+func (v Vec) Sub(o Vec) Vec {
+	return Vec{v.X - o.X, v.Y - o.Y}
+}
+
+func (v Vec) Scale(f float64) Vec {
+	return Vec{v.X * f, v.Y * f}
+}
+
+func (v Vec) Dot(o Vec) float64 {
+	return v.X*o.X + v.Y*o.Y
+}
+
+// Cross is the z component of the 3D cross product, positive when o is clockwise from v
+func (v Vec) Cross(o Vec) float64 {
+	return v.X*o.Y - v.Y*o.X
+}
+
+func (v Vec) Len() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
+func (v Vec) Dist(o Vec) float64 {
+	return o.Sub(v).Len()
+}
+
+// Normalize returns v scaled to length 1, or the zero vector if v has no length
+func (v Vec) Normalize() Vec {
+	l := v.Len()
+	if l == 0 {
+		return Vec{}
+	}
+	return v.Scale(1 / l)
+}
+
+// Angle is the direction of v in degrees, measured clockwise from the x axis
+func (v Vec) Angle() float64 {
+	return math.Atan2(v.Y, v.X) * (180.0 / math.Pi)
+}
+
+func (v Vec) Rotate(degree float64) Vec {
+	sin, cos := math.Sincos(DegreeToRad(degree))
+	return Vec{v.X*cos - v.Y*sin, v.X*sin + v.Y*cos}
+}
+
+// Perp is v rotated a quarter turn clockwise
+func (v Vec) Perp() Vec {
+	return Vec{-v.Y, v.X}
+}
+
+// Pixel rounds v to the nearest whole pixel, for drawing
+func (v Vec) Pixel() Pos {
+	return Pos{int(math.Round(v.X)), int(math.Round(v.Y))}
+}
+
+func (p Pos) Vec() Vec {
+	return Vec{float64(p.X), float64(p.Y)}
+}
+
+// AngleTo is the signed angle in degrees to turn v through to point along o, positive clockwise
+func (v Vec) AngleTo(o Vec) float64 {
+	return math.Atan2(v.Cross(o), v.Dot(o)) * (180.0 / math.Pi)
+}
+
+// TurnToward turns the unit vector v toward the direction of o by at most maxDegrees
+func (v Vec) TurnToward(o Vec, maxDegrees float64) Vec {
+	dir := o.Normalize()
+	if dir == (Vec{}) {
+		return v
+	}
+	angle := v.AngleTo(dir)
+	if math.Abs(angle) <= maxDegrees {
+		return dir
+	}
+	return v.Rotate(math.Copysign(maxDegrees, angle)).Normalize()
+}
@@ -0,0 +1,142 @@
+package game
+
+import (
+	"fmt"
+	"log"
+)
+
+// A Wave is a batch of enemies that starts Delay seconds after the previous wave finished
+// spawning, or after the game began for the first wave
+type Wave struct {
+	Delay float64 `json:"delay"`
+	// Income is paid to the player when the wave starts
+	Income int          `json:"income"`
+	Groups []*WaveGroup `json:"groups"`
+}
+
+// A WaveGroup spawns Count enemies of one type, Interval seconds apart, all at once with the
+// other groups in its wave
+type WaveGroup struct {
+	// Enemy names an archetype in the enemy catalog
+	Enemy    string  `json:"enemy"`
+	Count    int     `json:"count"`
+	Interval float64 `json:"interval"`
+	// Spawn is where in the world the enemies appear; without one they appear along a random edge of the view
+	Spawn *Vec `json:"spawn"`
+	// Lane, if set, names the lane the enemies spawn at the start of and drive along, instead of Spawn
+	Lane string `json:"lane"`
+}
+
+type WaveFile struct {
+	Waves []*Wave `json:"waves"`
+}
+
+func LoadWaves(filename string) ([]*Wave, error) {
+	file := &WaveFile{}
+	if err := loadJSON(filename, file); err != nil {
+		return nil, err
+	}
+	for i, wave := range file.Waves {
+		if wave.Delay < 0 {
+			return nil, fmt.Errorf("waves %s: wave %d has a negative delay", filename, i+1)
+		}
+		for _, group := range wave.Groups {
+			if group.Count < 0 || group.Interval < 0 {
+				return nil, fmt.Errorf("waves %s: wave %d has a group with a negative count or interval", filename, i+1)
+			}
+		}
+	}
+	return file.Waves, nil
+}
+
+// WaveDirector spawns enemies as the waves it was given describe, counting time in
+// simulation seconds so it plays out the same at any tick rate
+type WaveDirector struct {
+	Waves []*Wave
+	// Current is the number of waves started so far, so it is also the 1-based number of the latest one
+	Current int
+	// Countdown is the seconds left until the next wave starts, while waiting for one
+	Countdown float64
+	spawning  []*groupSpawn
+}
+
+type groupSpawn struct {
+	group   *WaveGroup
+	spawned int
+	timer   float64
+}
+
+func NewWaveDirector(waves []*Wave) *WaveDirector {
+	director := &WaveDirector{}
+	director.Waves = waves
+	director.Current = 0
+	if len(waves) > 0 {
+		director.Countdown = waves[0].Delay
+	}
+	return director
+}
+
+// Waiting reports whether the director is counting down to another wave
+func (director *WaveDirector) Waiting() bool {
+	return len(director.spawning) == 0 && director.Current < len(director.Waves)
+}
+
+// Done reports whether every wave has finished spawning
+func (director *WaveDirector) Done() bool {
+	return len(director.spawning) == 0 && director.Current == len(director.Waves)
+}
+
+// Update advances the director by dt seconds, starting waves and spawning their enemies into level
+func (director *WaveDirector) Update(level *Level, dt float64) {
+	if director.Waiting() {
+		director.Countdown -= dt
+		if director.Countdown > 0 {
+			return
+		}
+		director.start(level)
+	}
+
+	active := director.spawning[:0]
+	for _, spawn := range director.spawning {
+		spawn.timer -= dt
+		for spawn.spawned < spawn.group.Count && spawn.timer <= 0 {
+			at := spawn.group.Spawn
+			lane := level.Lanes[spawn.group.Lane]
+			if lane != nil {
+				start := lane.Start(level.Map)
+				at = &start
+			}
+			enemy, err := level.SpawnEnemy(spawn.group.Enemy, at)
+			if err != nil {
+				// NewGame checks every group names a known enemy, so this only happens to waves
+				// handed to the director some other way; the group is dropped
+				log.Printf("wave %d: %v", director.Current, err)
+				spawn.spawned = spawn.group.Count
+				break
+			}
+			if lane != nil {
+				enemy.JoinLane(lane)
+			}
+			spawn.spawned++
+			spawn.timer += spawn.group.Interval
+		}
+		if spawn.spawned < spawn.group.Count {
+			active = append(active, spawn)
+		}
+	}
+	director.spawning = active
+
+	if director.Waiting() {
+		director.Countdown = director.Waves[director.Current].Delay
+	}
+}
+
+func (director *WaveDirector) start(level *Level) {
+	wave := director.Waves[director.Current]
+	director.Current++
+	director.Countdown = 0
+	level.Ledger.Earn(level.Tick, wave.Income, WaveIncome)
+	for _, group := range wave.Groups {
+		director.spawning = append(director.spawning, &groupSpawn{group: group})
+	}
+}
@@ -0,0 +1,87 @@
+package game
+
+import (
+	"io"
+	"log"
+	"testing"
+)
+
+func TestWaveDirectorUpdate(t *testing.T) {
+	// Steps of a quarter second add up exactly, so the spawn times below are exact too
+	const dt = 0.25
+	waves := []*Wave{
+		{Delay: 1, Groups: []*WaveGroup{{Enemy: "tank", Count: 3, Interval: 0.5}}},
+		{Delay: 2, Income: 25, Groups: []*WaveGroup{
+			{Enemy: "tank", Count: 2, Interval: 0, Spawn: &Vec{200, 200}},
+			{Enemy: "tank", Count: 1, Interval: 0},
+		}},
+	}
+	tests := []struct {
+		seconds float64
+		enemies int
+		current int
+		income  int
+		done    bool
+	}{
+		{0.75, 0, 0, 0, false},
+		{1, 1, 1, 0, false},
+		{1.25, 2, 1, 0, false},
+		{1.5, 2, 1, 0, false},
+		{1.75, 3, 1, 0, false},
+		{3.5, 3, 1, 0, false},
+		{3.75, 6, 2, 25, true},
+		{10, 6, 2, 25, true},
+	}
+
+	game := newTestGame(t, 1)
+	level := game.Level
+	start := level.Ledger.Balance()
+	director := NewWaveDirector(waves)
+	elapsed := 0.0
+	for _, test := range tests {
+		for elapsed < test.seconds {
+			director.Update(level, dt)
+			elapsed += dt
+		}
+		if len(level.Enemies) != test.enemies {
+			t.Errorf("after %vs: %d enemies, want %d", test.seconds, len(level.Enemies), test.enemies)
+		}
+		if director.Current != test.current {
+			t.Errorf("after %vs: wave %d, want %d", test.seconds, director.Current, test.current)
+		}
+		if income := level.Ledger.Balance() - start; income != test.income {
+			t.Errorf("after %vs: earned %d, want %d", test.seconds, income, test.income)
+		}
+		if director.Done() != test.done {
+			t.Errorf("after %vs: Done = %v, want %v", test.seconds, director.Done(), test.done)
+		}
+	}
+
+	spawned := 0
+	for _, enemy := range level.Enemies {
+		if enemy.Vec == (Vec{200, 200}) {
+			spawned++
+		}
+	}
+	if spawned != 2 {
+		t.Errorf("%d enemies spawned at the group's spawn point, want 2", spawned)
+	}
+}
+
+func TestWaveDirectorDropsUnknownEnemies(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	game := newTestGame(t, 1)
+	director := NewWaveDirector([]*Wave{{Groups: []*WaveGroup{
+		{Enemy: "nobody", Count: 3, Interval: 1},
+		{Enemy: "tank", Count: 1},
+	}}})
+	director.Update(game.Level, 0.25)
+	if len(game.Level.Enemies) != 1 {
+		t.Errorf("%d enemies spawned, want only the known one", len(game.Level.Enemies))
+	}
+	if !director.Done() {
+		t.Error("the unknown group is still spawning")
+	}
+}
@@ -0,0 +1,141 @@
+package game
+
+import (
+	"fmt"
+	"math"
+)
+
+// WeaponSpec describes how a weapon fires
+type WeaponSpec struct {
+	Name   string   `json:"-"`
+	Bullet SpriteID `json:"bullet"`
+	Damage int      `json:"damage"`
+	// Speed is how fast its projectiles fly, in pixels per second
+	Speed float64 `json:"speed"`
+	// Cooldown is the seconds between shots
+	Cooldown float64 `json:"cooldown"`
+	// Ammo is how many shots the weapon holds; 0 means it never runs out
+	Ammo int `json:"ammo"`
+	// Pellets is how many projectiles each shot fires, at least 1
+	Pellets int `json:"pellets"`
+	// Spread is the angle in degrees a shot's pellets fan out across. A single pellet strays
+	// somewhere within it instead.
+	Spread float64 `json:"spread"`
+	// TurnRate, when set, makes the weapon fire missiles that steer toward the enemy nearest the
+	// reticle, within LockRadius of it, at up to this many degrees per second
+	TurnRate   float64 `json:"turnRate"`
+	LockRadius float64 `json:"lockRadius"`
+	Blast      Blast   `json:"blast"`
+	// Range, in pixels, and Lifetime, in seconds, limit how far the weapon's projectiles fly;
+	// either left out gets the default. Expire is what they do when they get there.
+	Range    float64 `json:"range"`
+	Lifetime float64 `json:"lifetime"`
+	Expire   Expiry  `json:"expire"`
+}
+
+// WeaponCatalog holds every weapon by name, and the player's loadout
+type WeaponCatalog struct {
+	Primary   string                 `json:"primary"`
+	Secondary []string               `json:"secondary"`
+	Weapons   map[string]*WeaponSpec `json:"weapons"`
+}
+
+func LoadWeaponCatalog(filename string) (*WeaponCatalog, error) {
+	catalog := &WeaponCatalog{}
+	if err := loadJSON(filename, catalog); err != nil {
+		return nil, err
+	}
+	for name, spec := range catalog.Weapons {
+		spec.Name = name
+		if spec.Pellets < 1 {
+			spec.Pellets = 1
+		}
+	}
+	if err := catalog.validate(); err != nil {
+		return nil, fmt.Errorf("weapon catalog %s: %v", filename, err)
+	}
+	return catalog, nil
+}
+
+func (catalog *WeaponCatalog) validate() error {
+	for _, name := range append([]string{catalog.Primary}, catalog.Secondary...) {
+		if catalog.Weapons[name] == nil {
+			return fmt.Errorf("loadout names undefined weapon %q", name)
+		}
+	}
+	return nil
+}
+
+// WeaponSlot is a weapon the player carries, with its own cooldown and ammo
+type WeaponSlot struct {
+	Spec *WeaponSpec
+	// Timer counts seconds since the last shot, up to the weapon's cooldown
+	Timer float64
+	Ammo  int
+	// Firing is set while the weapon's trigger is held
+	Firing bool
+}
+
+func NewWeaponSlot(spec *WeaponSpec) *WeaponSlot {
+	slot := &WeaponSlot{}
+	slot.Spec = spec
+	slot.Timer = spec.Cooldown
+	slot.Ammo = spec.Ammo
+	return slot
+}
+
+// Ready reports whether the weapon can fire this tick
+func (slot *WeaponSlot) Ready() bool {
+	return slot.Timer >= slot.Spec.Cooldown && (slot.Spec.Ammo == 0 || slot.Ammo > 0)
+}
+
+// Update counts dt seconds toward the weapon's next shot
+func (slot *WeaponSlot) Update(dt float64) {
+	slot.Timer = math.Min(slot.Timer+dt, slot.Spec.Cooldown)
+}
+
+// fireWeapon fires a shot from slot out of firedBy's muzzle if the slot is ready and its trigger is held
+func (level *Level) fireWeapon(slot *WeaponSlot, firedBy *Character) {
+	if !slot.Firing || !slot.Ready() {
+		return
+	}
+	spec := slot.Spec
+	var target *Enemy
+	if spec.TurnRate > 0 {
+		target = level.LockTarget(spec)
+	}
+	for i := 0; i < spec.Pellets; i++ {
+		var angle float64
+		if spec.Pellets == 1 && spec.Spread > 0 {
+			angle = (level.Rand.Float64() - 0.5) * spec.Spread
+		} else if spec.Pellets > 1 {
+			angle = -spec.Spread/2 + spec.Spread*float64(i)/float64(spec.Pellets-1)
+		}
+		bullet := level.InitBullet(spec.Bullet, firedBy)
+		bullet.Facing = firedBy.Facing.Rotate(angle)
+		bullet.Speed = spec.Speed
+		bullet.Vel = bullet.Facing.Scale(bullet.Speed)
+		bullet.Damage = spec.Damage
+		bullet.TurnRate = spec.TurnRate
+		bullet.Target = target
+		bullet.Blast = spec.Blast
+		bullet.Expire = spec.Expire
+		if spec.Range > 0 {
+			bullet.Range = spec.Range
+		}
+		if spec.Lifetime > 0 {
+			bullet.Lifetime = spec.Lifetime
+		}
+		level.Bullets = append(level.Bullets, bullet)
+	}
+	slot.Timer = 0
+	if spec.Ammo > 0 {
+		slot.Ammo--
+	}
+}
+
+// LockTarget is the enemy a homing weapon would lock onto: the one nearest the player's reticle,
+// within the weapon's lock radius of it
+func (level *Level) LockTarget(spec *WeaponSpec) *Enemy {
+	return level.SelectTarget(TargetNearest, level.Player.Aim, spec.LockRadius)
+}
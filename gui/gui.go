@@ -7,37 +7,30 @@ import (
 	"github.com/veandco/go-sdl2/ttf"
 	"image/png"
 	"io/ioutil"
+	"math"
 	"os"
 	"strconv"
 	"time"
 )
 
-type GameTile struct {
-	TextureName string
-	IsTrack bool
-	game.Pos
-}
-
 type ui struct {
-	WinWidth                                     int
-	WinHeight                                    int
-	renderer                                     *sdl.Renderer
-	window                                       *sdl.Window
-	font                                         *ttf.Font
-	textureMap                                   map[string]*sdl.Texture
-	keyboardState                                []uint8
-	inputChan                                    chan *game.Input
-	levelChan                                    chan *game.Level
-	currentMouseX                                int32
-	currentMouseY                                int32
-	playerInit                                   bool
-	levelMap                                     [][]*GameTile
-	tileMap                                      map[int]string
-	testMap                                      [][]*GameTile
-	topBound, bottomBound, leftBound, rightBound int
-	fontTextureMap                               map[string]*sdl.Texture
-	mapMoveDelay                                 int
-	mapMoveTimer                                 int
+	WinWidth       int
+	WinHeight      int
+	renderer       *sdl.Renderer
+	window         *sdl.Window
+	font           *ttf.Font
+	textureMap     map[game.SpriteID]*sdl.Texture
+	keyboardState  []uint8
+	inputChan      chan *game.Input
+	snapshotChan   chan *game.Snapshot
+	currentMouseX  int32
+	currentMouseY  int32
+	playerInit     bool
+	fontTextureMap map[string]*sdl.Texture
+	// camera is the world position of the view's top left corner in the snapshot being drawn
+	camera game.Vec
+	// callouts are the recent changes to the balance still being shown
+	callouts []game.LedgerEvent
 }
 
 func init() {
@@ -49,57 +42,13 @@ func init() {
 	}
 }
 
-func NewUi(inputChan chan *game.Input, levelChan chan *game.Level) *ui {
+func NewUi() *ui {
 	ui := &ui{}
-	ui.inputChan = inputChan
-	ui.levelChan = levelChan
 	ui.WinHeight = 1080
 	ui.WinWidth = 1920
-	ui.topBound = int(float32(ui.WinHeight) * 0.25)
-	ui.bottomBound = int(float32(ui.WinHeight) * 0.75)
-	ui.leftBound = int(float32(ui.WinWidth) * 0.25)
-	ui.rightBound = int(float32(ui.WinWidth) * 0.75)
-	ui.textureMap = make(map[string]*sdl.Texture)
+	ui.textureMap = make(map[game.SpriteID]*sdl.Texture)
 	ui.fontTextureMap = make(map[string]*sdl.Texture)
 	ui.playerInit = false
-	ui.mapMoveTimer = 0
-	ui.mapMoveDelay = 5
-
-	if ui.WinHeight%128 != 0 {
-		ui.levelMap = make([][]*GameTile, (ui.WinHeight/128)+1)
-	} else {
-		ui.levelMap = make([][]*GameTile, ui.WinHeight/128)
-	}
-	for i := range ui.levelMap {
-		ui.levelMap[i] = make([]*GameTile, ui.WinWidth/128)
-	}
-	ui.testMap = make([][]*GameTile, 300)
-	for i := range ui.testMap {
-		ui.testMap[i] = make([]*GameTile, 300)
-	}
-	for y := range ui.testMap {
-		for x := range ui.testMap[y] {
-			if y%2 == 0 {
-				if x%2 == 0 {
-					ui.testMap[y][x] = &GameTile{"tileGrass1", false, game.Pos{x, y}}
-				} else {
-					ui.testMap[y][x] = &GameTile{"tileSand1", false, game.Pos{x, y}}
-				}
-			} else {
-				if x%2 == 0 {
-					ui.testMap[y][x] = &GameTile{"tileSand1", false, game.Pos{x, y}}
-				} else {
-					ui.testMap[y][x] = &GameTile{"tileGrass1", false, game.Pos{x, y}}
-				}
-			}
-		}
-	}
-	for y := range ui.levelMap {
-		for x := range ui.levelMap[y] {
-			ui.levelMap[y][x] = ui.testMap[y][x]
-		}
-	}
-	ui.tileMap = make(map[int]string)
 	var err error
 	ui.window, err = sdl.CreateWindow("Towers", sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED, int32(ui.WinWidth), int32(ui.WinHeight), sdl.WINDOW_SHOWN)
 	if err != nil {
@@ -135,68 +84,17 @@ func (ui *ui) loadTextures(dirName string) {
 		filename := file.Name()[:len(file.Name())-4]
 		filepath := dirName + "/" + file.Name()
 		tex := imgFileToTexture(ui.renderer, filepath)
-		ui.textureMap[filename] = tex
+		ui.textureMap[game.SpriteID(filename)] = tex
 	}
 }
 
-// TODO: Need to rework this to draw a meaningful map
-func (ui *ui) DrawGround(level *game.Level) {
-	// How do I know which part of the testMap to draw as the ground, and how do I change that as the player moves around?
-	p := level.Player
-
-	if ui.mapMoveTimer >= ui.mapMoveDelay && (p.AtTop || p.AtBottom || p.AtLeft || p.AtRight) {
-		// Need to update level map
-		ui.mapMoveTimer = 0
-		for y := range ui.levelMap {
-			for x := range ui.levelMap[y] {
-				currentTile := ui.levelMap[y][x]
-				newX, newY := currentTile.X, currentTile.Y
-				if p.AtBottom {
-					newY += 1
-				}
-				if p.AtRight {
-					newX += 1
-				}
-				if p.AtLeft {
-					newX -= 1
-				}
-				if p.AtTop {
-					newY -= 1
-				}
-				if newY < 0 {
-					newY = 0
-				}
-				if newY >= len(ui.testMap) {
-					newY = len(ui.testMap) - 1
-				}
-				if newX < 0 {
-					newX = 0
-				}
-				if newX >= len(ui.testMap[y]) {
-					newX = len(ui.testMap[y]) - 1
-				}
-				if (newY < len(ui.testMap) && newY > 0) || (newX < len(ui.testMap[y]) && newX > 0) {
-					fmt.Println("Getting tile at", newX, newY)
-					nextTile := ui.testMap[newY][newX]
-					//fmt.Println("Next Tile Texture", nextTile.TextureName)
-					destRect := &sdl.Rect{int32(currentTile.X * 128), int32(currentTile.Y * 128), 128, 128}
-					ui.renderer.Copy(ui.textureMap[nextTile.TextureName], nil, destRect)
-					ui.levelMap[y][x].TextureName = nextTile.TextureName
-				}
-			}
-		}
-	} else {
-		// Draw level map without updating it
-		ui.mapMoveTimer++
-		for y := range ui.levelMap {
-			for x := range ui.levelMap[y] {
-				currentTile := ui.levelMap[y][x]
-				destRect := &sdl.Rect{int32(currentTile.X * 128), int32(currentTile.Y * 128), 128, 128}
-				ui.renderer.Copy(ui.textureMap[currentTile.TextureName], nil, destRect)
-			}
-		}
+func (ui *ui) DrawGround(snapshot *game.Snapshot) {
+	for _, tile := range snapshot.Ground {
+		ui.drawSprite(tile)
+	}
+	for _, obstacle := range snapshot.Obstacles {
+		ui.drawSprite(obstacle)
 	}
-
 }
 
 func (ui *ui) DrawCursor() {
@@ -208,15 +106,119 @@ func (ui *ui) DrawCursor() {
 	ui.renderer.Copy(tex, nil, &sdl.Rect{ui.currentMouseX - w/8, ui.currentMouseY - h/8, w / 4, h / 4})
 }
 
-func (ui *ui) DrawUiElements(level *game.Level) {
-	p := level.Player
-	tex := ui.stringToTexture(strconv.Itoa(p.Hitpoints)+" HP", sdl.Color{255, 255, 255, 1})
+// DrawLockOn rings the enemy a homing weapon is locked onto with a red reticle
+func (ui *ui) DrawLockOn(snapshot *game.Snapshot) {
+	lock := snapshot.Lock
+	if !lock.Active {
+		return
+	}
+	tex := ui.textureMap["cross-02"]
+	size := lock.W
+	if lock.H > size {
+		size = lock.H
+	}
+	topLeft := lock.Sub(ui.camera).Sub(game.Vec{float64(size) / 2, float64(size) / 2}).Pixel()
+	tex.SetColorMod(255, 0, 0)
+	ui.renderer.Copy(tex, nil, &sdl.Rect{int32(topLeft.X), int32(topLeft.Y), int32(size), int32(size)})
+	tex.SetColorMod(255, 255, 255)
+}
+
+// addCallouts queues the changes to the balance a newly received snapshot brings. It runs once
+// per snapshot rather than per frame, since a snapshot may be drawn several times.
+func (ui *ui) addCallouts(snapshot *game.Snapshot) {
+	for _, event := range snapshot.HUD.Transactions {
+		if event.Reason != game.StartingFunds {
+			ui.callouts = append(ui.callouts, event)
+		}
+	}
+}
+
+func (ui *ui) DrawUiElements(snapshot *game.Snapshot) {
+	hud := snapshot.HUD
+	x := ui.drawText(strconv.Itoa(hud.Hitpoints)+" HP", 0, 0, false) + 32
+	x += ui.drawText(strconv.Itoa(hud.Lives)+" lives", x, 0, false) + 32
+	x += ui.drawText(fmt.Sprintf("Base %d/%d", hud.BaseHitpoints, hud.BaseMaxHitpoints), x, 0, false) + 32
+	x += ui.drawText(strconv.Itoa(hud.Currency)+" $", x, 0, false) + 32
+	// Call out each change to the balance for a couple of seconds, the newest few at the top
+	callouts := ui.callouts[:0]
+	for _, event := range ui.callouts {
+		if snapshot.Tick-event.Tick < 2*hud.TickRate {
+			callouts = append(callouts, event)
+		}
+	}
+	ui.callouts = callouts
+	for i := 0; i < len(callouts) && i < 5; i++ {
+		event := callouts[len(callouts)-1-i]
+		y := int32(i) * 32
+		if event.Rejected {
+			ui.drawText("cannot afford "+event.Reason.String(), x, y, false)
+		} else {
+			ui.drawText(fmt.Sprintf("%+d %s", event.Amount, event.Reason), x, y, false)
+		}
+	}
+	ui.drawText("Seed "+strconv.FormatInt(hud.Seed, 10), int32(ui.WinWidth), 0, true)
+	wave := fmt.Sprintf("Wave %d/%d", hud.Wave, hud.Waves)
+	if hud.Countdown > 0 {
+		wave += fmt.Sprintf("  next in %.0f", math.Ceil(hud.Countdown))
+	}
+	ui.drawText(wave, int32(ui.WinWidth)/2, 0, false)
+	if hud.Tower.Active {
+		ui.drawTowerInfo(hud.Tower)
+	}
+	ui.drawWeapons(hud.Weapons)
+}
+
+// drawWeapons lists the secondary weapons up the bottom left corner, marking the one in hand
+func (ui *ui) drawWeapons(weapons []game.WeaponInfo) {
+	y := int32(ui.WinHeight) - 32*int32(len(weapons))
+	for i, weapon := range weapons {
+		line := strconv.Itoa(i+1) + " " + weapon.Name
+		if weapon.Ammo >= 0 {
+			line += " " + strconv.Itoa(weapon.Ammo)
+		}
+		if weapon.Selected {
+			line = "> " + line
+		}
+		ui.drawText(line, 0, y, false)
+		y += 32
+	}
+}
+
+// upgradeKeys are the keys that pick each upgrade option, in option order
+var upgradeKeys = []string{"U", "I", "O"}
+
+// drawTowerInfo lists the hovered tower's stats and what can be done with it beside the cursor
+func (ui *ui) drawTowerInfo(tower game.TowerInfo) {
+	x, y := ui.currentMouseX+16, ui.currentMouseY+16
+	ui.drawText(tower.Name+" L"+strconv.Itoa(tower.Level)+" target "+tower.Policy, x, y, false)
+	for i, upgrade := range tower.Upgrades {
+		if i >= len(upgradeKeys) {
+			break
+		}
+		line := upgradeKeys[i] + " " + upgrade.Name + " " + strconv.Itoa(upgrade.Cost)
+		if !upgrade.Affordable {
+			line += " (need more)"
+		}
+		y += 32
+		ui.drawText(line, x, y, false)
+	}
+	y += 32
+	ui.drawText("X sell "+strconv.Itoa(tower.SellValue), x, y, false)
+}
+
+// drawText draws s with its top left corner at x, y, or its top right corner if alignRight is set,
+// and returns the width of the text
+func (ui *ui) drawText(s string, x, y int32, alignRight bool) int32 {
+	tex := ui.stringToTexture(s, sdl.Color{255, 255, 255, 1})
 	_, _, w, h, err := tex.Query()
 	if err != nil {
 		panic(err)
 	}
-
-	ui.renderer.Copy(tex, nil, &sdl.Rect{0, 0, w, h})
+	if alignRight {
+		x -= w
+	}
+	ui.renderer.Copy(tex, nil, &sdl.Rect{x, y, w, h})
+	return w
 }
 
 func (ui *ui) stringToTexture(s string, color sdl.Color) *sdl.Texture {
@@ -231,191 +233,127 @@ func (ui *ui) stringToTexture(s string, color sdl.Color) *sdl.Texture {
 	if err != nil {
 		panic(err)
 	}
+	ui.fontTextureMap[s] = tex
 	return tex
 }
 
-func (ui *ui) DrawPlayer(level *game.Level) {
-	if level.Player.IsFiring {
-		level.Player.FireRateTimer++
+func (ui *ui) drawSprite(sprite game.Sprite) {
+	tex := ui.textureMap[sprite.ID]
+	topLeft := sprite.Sub(ui.camera).Sub(game.Vec{float64(sprite.W) / 2, float64(sprite.H) / 2}).Pixel()
+	ui.renderer.CopyEx(tex, nil, &sdl.Rect{int32(topLeft.X), int32(topLeft.Y), int32(sprite.W), int32(sprite.H)}, sprite.Direction, nil, sdl.FLIP_NONE)
+}
+
+// drawEffect draws tex centered on the effect at scale times the texture's size
+func (ui *ui) drawEffect(effect game.Effect, tex *sdl.Texture, scale float64) {
+	_, _, w, h, err := tex.Query()
+	if err != nil {
+		panic(err)
 	}
-	if level.Player.Texture == nil {
-		tex := ui.textureMap[level.Player.TextureName]
-		level.Player.Texture = tex
-		_, _, w, h, err := tex.Query()
-		if err != nil {
-			panic(err)
-		}
-		level.Player.W = int(w)
-		level.Player.H = int(h)
-		level.Player.X = ui.WinWidth/2 - level.Player.W/2
-		level.Player.Y = ui.WinHeight/2 - level.Player.H/2
-		level.Player.FireOffsetX = 0
-		level.Player.FireOffsetY = 0
-	}
-	player := level.Player
-	tex := player.Texture
-	player.Direction = game.FindDegreeRotation(int32(player.Y+player.H/2), int32(player.X+player.W/2), ui.currentMouseY, ui.currentMouseX) - 90
-	player.Move(ui.topBound, ui.bottomBound, ui.leftBound, ui.rightBound)
-	ui.renderer.CopyEx(tex, nil, &sdl.Rect{int32(player.X), int32(player.Y), int32(player.W), int32(player.H)}, float64(player.Direction), nil, 0)
+	w = int32(float64(w) * scale)
+	h = int32(float64(h) * scale)
+	center := effect.Sub(ui.camera).Pixel()
+	ui.renderer.CopyEx(tex, nil, &sdl.Rect{int32(center.X) - w/2, int32(center.Y) - h/2, w, h}, effect.Direction, nil, sdl.FLIP_NONE)
+}
 
+func (ui *ui) DrawPlayer(snapshot *game.Snapshot) {
+	ui.drawSprite(snapshot.Player)
 }
 
-func (ui *ui) SpawnEnemies(level *game.Level) {
-	if level.EnemySpawnTimer >= 100 && len(level.Enemies) < 1 {
-		level.Enemies = append(level.Enemies, level.InitEnemy())
-		level.EnemySpawnTimer = 0
-	} else {
-		level.EnemySpawnTimer++
+func (ui *ui) DrawEnemy(snapshot *game.Snapshot) {
+	for _, enemy := range snapshot.Enemies {
+		ui.drawSprite(enemy)
 	}
 }
 
-func (ui *ui) DrawEnemy(level *game.Level) {
-	player := level.Player
-	for _, enemy := range level.Enemies {
-		if enemy.Texture == nil {
-			tex := ui.textureMap[enemy.TextureName]
-			enemy.Texture = tex
-			_, _, w, h, err := tex.Query()
-			if err != nil {
-				panic(err)
-			}
-			enemy.W = int(w)
-			enemy.H = int(h)
-			enemy.FireOffsetX = int(w / 2)
-			enemy.FireOffsetY = 0
-		}
-		if !enemy.IsDestroyed {
-			//enemy.Update(level)
-			ui.CheckFiring(level, enemy)
-			enemy.Direction = game.FindDegreeRotation(int32(enemy.Y), int32(enemy.X), int32(player.Y), int32(player.X)) - 90
-			ui.renderer.CopyEx(enemy.Texture, nil, &sdl.Rect{int32(enemy.X), int32(enemy.Y), int32(enemy.W), int32(enemy.H)}, float64(enemy.Direction), nil, sdl.FLIP_NONE)
-		}
-	}
+func (ui *ui) DrawBase(snapshot *game.Snapshot) {
+	ui.drawSprite(snapshot.Base)
 }
 
-func (ui *ui) DrawExplosions(level *game.Level) {
-	index := 0
-	for _, enemy := range level.Enemies {
-		if enemy.IsDestroyed && !enemy.DestroyedAnimationPlayed {
-			seconds := sdl.GetTicks() * 1000
-			imageNumber := seconds % 9
-			imageName := "explosion0" + strconv.Itoa(int(imageNumber))
-			tex := ui.textureMap[imageName]
-			_, _, w, h, err := tex.Query()
-			if err != nil {
-				panic(err)
-			}
-			ui.renderer.Copy(tex, nil, &sdl.Rect{int32(enemy.X) - w/16, int32(enemy.Y) - h/16, w / 4, h / 4})
-			enemy.DestroyedAnimationCounter++
-			if enemy.DestroyedAnimationCounter == 24 {
-				enemy.DestroyedAnimationPlayed = true
-			}
-			// WHY DOES THIS NOT WORK THE SAME AS THE BULLETS???!!!
-			if !enemy.DestroyedAnimationPlayed {
-				level.Enemies[index] = enemy
-				index++
-			}
-		} else {
-			if !enemy.IsDestroyed && !enemy.DestroyedAnimationPlayed {
-				level.Enemies[index] = enemy
-				index++
-			}
-		}
+// DrawOutcome dims the view and announces victory or defeat once the level has ended
+func (ui *ui) DrawOutcome(snapshot *game.Snapshot) {
+	var title string
+	switch snapshot.Outcome {
+	case game.Victory:
+		title = "VICTORY"
+	case game.Defeat:
+		title = "DEFEAT"
+	default:
+		return
 	}
-	level.Enemies = append(level.Enemies[:index])
+	ui.renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	ui.renderer.SetDrawColor(0, 0, 0, 160)
+	ui.renderer.FillRect(&sdl.Rect{0, 0, int32(ui.WinWidth), int32(ui.WinHeight)})
+	ui.renderer.SetDrawColor(0, 0, 0, 255)
+	tex := ui.stringToTexture(title, sdl.Color{255, 255, 255, 1})
+	_, _, w, h, err := tex.Query()
+	if err != nil {
+		panic(err)
+	}
+	// Draw the title at three times the font size, in the middle of the view
+	w, h = w*3, h*3
+	ui.renderer.Copy(tex, nil, &sdl.Rect{(int32(ui.WinWidth) - w) / 2, (int32(ui.WinHeight) - h) / 2, w, h})
+	hud := snapshot.HUD
+	summary := fmt.Sprintf("Wave %d/%d  %d lives  Base %d/%d", hud.Wave, hud.Waves, hud.Lives, hud.BaseHitpoints, hud.BaseMaxHitpoints)
+	tex = ui.stringToTexture(summary, sdl.Color{255, 255, 255, 1})
+	_, _, w, _, err = tex.Query()
+	if err != nil {
+		panic(err)
+	}
+	ui.drawText(summary, (int32(ui.WinWidth)-w)/2, (int32(ui.WinHeight)+h)/2+16, false)
 }
 
-func (ui *ui) CheckFiring(level *game.Level, entity game.Shooter) {
-	timer, reset, isPlayer := entity.GetFireSettings()
-	if timer >= reset {
-		var texName string
-		if isPlayer {
-			texName = "bulletBlue1"
-		} else {
-			texName = "bulletRed1"
-		}
-		bullet := level.InitBullet(texName)
-		bullet.FiredByEnemy = !isPlayer
-		bullet.FiredBy = entity.GetSelf()
-		bullet.Damage = bullet.FiredBy.Strength
-		level.Bullets = append(level.Bullets, bullet)
-		entity.SetFireTimer(0)
-	} else if !isPlayer {
-		entity.SetFireTimer(timer + 1)
+func (ui *ui) DrawTowers(snapshot *game.Snapshot) {
+	for _, tower := range snapshot.Towers {
+		ui.drawSprite(tower)
 	}
 }
 
-func (ui *ui) DrawBullet(level *game.Level) {
-	index := 0
-	for i, bullet := range level.Bullets {
-		if bullet.Texture == nil {
-			tex := ui.textureMap[bullet.TextureName]
-			bullet.Texture = tex
-			_, _, w, h, err := tex.Query()
-			if err != nil {
-				panic(err)
-			}
-			bullet.W = int(w)
-			bullet.H = int(h)
-			bullet.Direction = bullet.FiredBy.Direction
-			bullet.X = (bullet.FiredBy.X + bullet.FiredBy.W/2) - bullet.W/2 + bullet.FiredBy.FireOffsetX
-			bullet.Y = (bullet.FiredBy.Y + bullet.FiredBy.H/2) - bullet.H/2 + bullet.FiredBy.FireOffsetY
-		}
-		tex := bullet.Texture
-		bullet.Update()
-
-		// Fire Animation
-		if bullet.FlashCounter < 5 && !bullet.FireAnimationPlayed {
-			fireTex := ui.textureMap["explosionSmoke2"]
-			_, _, w, h, err := fireTex.Query()
-			if err != nil {
-				panic(err)
-			}
-			posX := (bullet.FiredBy.X + bullet.FiredBy.W/2) - int(w/4) + bullet.FiredBy.FireOffsetX
-			posY := (bullet.FiredBy.Y + bullet.FiredBy.H/2) - int(h/4) + bullet.FiredBy.FireOffsetY
-			ui.renderer.CopyEx(fireTex, nil, &sdl.Rect{int32(posX), int32(posY), w / 2, h / 2}, float64(bullet.Direction), nil, sdl.FLIP_NONE)
-			bullet.FlashCounter++
-		}
-		if bullet.FlashCounter >= 5 && !bullet.FireAnimationPlayed {
-			bullet.FlashCounter = 0
-			bullet.FireAnimationPlayed = true
-		}
+// DrawBuildPreview shows a faded tower on the tile under the cursor, tinted red if it cannot be built there
+func (ui *ui) DrawBuildPreview(snapshot *game.Snapshot) {
+	build := snapshot.Build
+	if !build.Active {
+		return
+	}
+	tex := ui.textureMap[build.ID]
+	tex.SetAlphaMod(128)
+	if !build.Valid {
+		tex.SetColorMod(255, 64, 64)
+	}
+	ui.drawSprite(build.Sprite)
+	tex.SetAlphaMod(255)
+	tex.SetColorMod(255, 255, 255)
+}
 
-		// Collision Animation && Normal Travel
-		if bullet.ExplodeCounter < 5 && bullet.IsColliding && !bullet.DestroyAnimationPlayed {
-			fireTex := ui.textureMap["explosion2"]
-			_, _, w, h, err := fireTex.Query()
-			if err != nil {
-				panic(err)
-			}
-			bullet.Xvel = 0
-			bullet.Yvel = 0
-			ui.renderer.CopyEx(fireTex, nil, &sdl.Rect{int32(bullet.X), int32(bullet.Y), w / 2, h / 2}, float64(bullet.Direction), nil, sdl.FLIP_NONE)
-			bullet.ExplodeCounter++
-		} else {
-			if bullet.ExplodeCounter >= 5 && bullet.IsColliding && !bullet.DestroyAnimationPlayed {
-				bullet.DestroyAnimationPlayed = true
-				bullet.ExplodeCounter = 0
-			}
-			//point := &sdl.Point{int32(bullet.FiredBy.X), int32(bullet.FiredBy.Y)}
-			ui.renderer.CopyEx(tex, nil, &sdl.Rect{int32(bullet.X), int32(bullet.Y), int32(bullet.W), int32(bullet.H)}, float64(bullet.Direction+180.0), nil, sdl.FLIP_NONE)
-		}
-		// Keep bullets in the slice that aren't out of bounds (drop the bullets that go off screen so they aren't redrawn)
-		if !ui.checkBulletOutOfBounds(bullet.X, bullet.Y, int32(bullet.W), int32(bullet.H)) && !bullet.DestroyAnimationPlayed {
-			if index != i {
-				level.Bullets[index] = bullet
-			}
-			index++
-		}
+func (ui *ui) DrawBullet(snapshot *game.Snapshot) {
+	for _, bullet := range snapshot.Bullets {
+		ui.drawSprite(bullet)
 	}
-	level.Bullets = level.Bullets[:index]
 }
 
-func (ui *ui) checkBulletOutOfBounds(x, y int, w, h int32) bool {
-	if x > ui.WinWidth+int(w) || x < int(0-w) || y > ui.WinHeight+int(h) || y < int(0-h) {
-		return true
+func (ui *ui) DrawEffects(snapshot *game.Snapshot) {
+	for _, effect := range snapshot.Effects {
+		switch effect.Type {
+		case game.MuzzleFlash:
+			ui.drawEffect(effect, ui.textureMap["explosionSmoke2"], 0.5)
+		case game.Impact:
+			ui.drawEffect(effect, ui.textureMap["explosion2"], 0.5)
+		case game.Fizzle:
+			imageNumber := effect.Frame * 25 / effect.Frames
+			ui.drawEffect(effect, ui.textureMap[game.SpriteID(fmt.Sprintf("whitePuff%02d", imageNumber))], 0.25)
+		case game.Explosion:
+			imageNumber := effect.Frame * 9 / effect.Frames
+			tex := ui.textureMap[game.SpriteID("explosion0"+strconv.Itoa(imageNumber))]
+			scale := 0.25
+			if effect.Size > 0 {
+				_, _, w, _, err := tex.Query()
+				if err != nil {
+					panic(err)
+				}
+				scale = effect.Size / float64(w)
+			}
+			ui.drawEffect(effect, tex, scale)
+		}
 	}
-	return false
 }
 
 func imgFileToTexture(renderer *sdl.Renderer, filename string) *sdl.Texture {
@@ -464,6 +402,11 @@ func determineInputType(event *sdl.KeyboardEvent) *game.Input {
 	input := &game.Input{}
 	switch event.Type {
 	case sdl.KEYDOWN:
+		// Holding a key down repeats it; every key either sets held state or acts once per press,
+		// so repeats are dropped rather than upgrading or toggling again
+		if event.Repeat != 0 {
+			return input
+		}
 		input.Pressed = true
 		switch event.Keysym.Scancode {
 		case sdl.SCANCODE_W:
@@ -476,6 +419,24 @@ func determineInputType(event *sdl.KeyboardEvent) *game.Input {
 			input.Type = game.Right
 		case sdl.SCANCODE_TAB:
 			input.Type = game.Pause
+		case sdl.SCANCODE_B:
+			input.Type = game.ToggleBuild
+		case sdl.SCANCODE_T:
+			input.Type = game.CycleTargetPolicy
+		case sdl.SCANCODE_U:
+			input.Type = game.UpgradeTower
+			input.Option = 0
+		case sdl.SCANCODE_I:
+			input.Type = game.UpgradeTower
+			input.Option = 1
+		case sdl.SCANCODE_O:
+			input.Type = game.UpgradeTower
+			input.Option = 2
+		case sdl.SCANCODE_X:
+			input.Type = game.SellTower
+		case sdl.SCANCODE_1, sdl.SCANCODE_2, sdl.SCANCODE_3, sdl.SCANCODE_4:
+			input.Type = game.SelectSecondary
+			input.Option = int(event.Keysym.Scancode - sdl.SCANCODE_1)
 		}
 	case sdl.KEYUP:
 		input.Pressed = false
@@ -490,6 +451,8 @@ func determineInputType(event *sdl.KeyboardEvent) *game.Input {
 			input.Type = game.Right
 		case sdl.SCANCODE_TAB:
 			input.Type = game.Pause
+		case sdl.SCANCODE_B:
+			input.Type = game.ToggleBuild
 		}
 	}
 	return input
@@ -522,43 +485,53 @@ func (ui *ui) determineMouseButtonInput(event *sdl.MouseButtonEvent) *game.Input
 }
 
 // Remember to always draw from the ground up
-func (ui *ui) Draw(level *game.Level) {
+func (ui *ui) Draw(snapshot *game.Snapshot) {
 	ui.renderer.Clear()
-	ui.DrawGround(level)
-	ui.DrawPlayer(level)
-	ui.SpawnEnemies(level)
-	ui.DrawEnemy(level)
-	ui.CheckFiring(level, level.Player)
-	ui.DrawBullet(level)
-	level.CheckBulletCollisions()
-	ui.DrawExplosions(level)
-	ui.DrawUiElements(level)
+	ui.camera = snapshot.Camera
+	ui.DrawGround(snapshot)
+	ui.DrawBase(snapshot)
+	ui.DrawTowers(snapshot)
+	ui.DrawBuildPreview(snapshot)
+	ui.DrawPlayer(snapshot)
+	ui.DrawEnemy(snapshot)
+	ui.DrawBullet(snapshot)
+	ui.DrawEffects(snapshot)
+	ui.DrawLockOn(snapshot)
+	ui.DrawUiElements(snapshot)
+	ui.DrawOutcome(snapshot)
 	ui.DrawCursor()
 	ui.renderer.Present()
 }
 
-func (ui *ui) Run() {
+func (ui *ui) Run(inputChan chan *game.Input, snapshotChan chan *game.Snapshot) {
+	ui.inputChan = inputChan
+	ui.snapshotChan = snapshotChan
+
 	// Aiming for 120 FPS
 	var targetFrameTime = 1.0 / 120.0 * 1000
 	var frameStart time.Time
 	var elapsedTime float64
-	var level *game.Level
+	var snapshot *game.Snapshot
 
 	for {
 		frameStart = time.Now()
 
 		select {
-		case newLevel := <-ui.levelChan:
-			level = newLevel
-			ui.Draw(level)
-			break
+		case newSnapshot := <-ui.snapshotChan:
+			snapshot = newSnapshot
+			if snapshot != nil {
+				ui.addCallouts(snapshot)
+			}
 		default:
-			ui.Draw(level)
+		}
+		if snapshot != nil {
+			ui.Draw(snapshot)
 		}
 
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
 			switch e := event.(type) {
 			case *sdl.QuitEvent:
+				ui.inputChan <- &game.Input{Type: game.Quit}
 				return
 			case *sdl.KeyboardEvent:
 				input := determineInputType(e)
@@ -568,6 +541,7 @@ func (ui *ui) Run() {
 			case *sdl.MouseMotionEvent:
 				ui.currentMouseX = e.X
 				ui.currentMouseY = e.Y
+				ui.inputChan <- &game.Input{Pos: game.Pos{int(e.X), int(e.Y)}, Type: game.Aim}
 			default:
 				ui.inputChan <- &game.Input{Type: game.None}
 			}
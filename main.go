@@ -1,16 +1,74 @@
 package main
 
 import (
-	"github.com/oxycleanman/towers/gui"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
 	"github.com/oxycleanman/towers/game"
+	"github.com/oxycleanman/towers/gui"
 )
 
+// Usage:
+//
+//	towers [-seed n] [-record file]   play, optionally recording the session to file
+//	towers replay file                play back a recorded session
 func main() {
-	game := game.NewGame()
+	record := flag.String("record", "", "record the session to this replay file")
+	seed := flag.Int64("seed", 0, "seed for all gameplay randomness (default: random)")
+	flag.Parse()
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	var replay *game.Replay
+	if flag.Arg(0) == "replay" {
+		var err error
+		replay, err = game.LoadReplay(flag.Arg(1))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		*seed = replay.Seed
+	}
+	log.Printf("seed %d", *seed)
+
+	data, err := game.LoadData("gui/assets/images", "game/data")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ui := gui.NewUi()
+	game, err := game.NewGame(ui.WinWidth, ui.WinHeight, data, *seed)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if replay != nil {
+		if err := game.Play(replay); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	} else if *record != "" {
+		game.Recording = game.NewReplay()
+	}
+
+	done := make(chan struct{})
 	go func() {
 		game.Run()
+		close(done)
 	}()
 
-	ui := gui.NewUi(game.InputChan, game.LevelChan)
-	ui.Run()
+	ui.Run(game.InputChan, game.SnapshotChan)
+	<-done
+
+	if game.Recording != nil {
+		if err := game.Recording.Save(*record); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
 }